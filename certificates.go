@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+const (
+	tlsCertificateKey = "tls.crt"
+
+	defaultMinRSAKeySize   = 2048
+	defaultMinECDSAKeySize = 256
+)
+
+// now returns the current time, the tests replace it to get
+// reproducible results
+var now = time.Now
+
+// parseCertificateChain returns the certificates found inside of the
+// tls.crt key of the secret. The first certificate is the leaf one.
+func parseCertificateChain(secret *corev1.Secret) ([]*x509.Certificate, error) {
+	data, found := secret.Data[tlsCertificateKey]
+	if !found || len(data) == 0 {
+		return nil, fmt.Errorf("the %s key is missing", tlsCertificateKey)
+	}
+
+	chain := []*x509.Certificate{}
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", tlsCertificateKey, err)
+		}
+		chain = append(chain, certificate)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found inside of %s", tlsCertificateKey)
+	}
+	return chain, nil
+}
+
+// The certificate must cover all the hosts of the TLS entry and must
+// be valid for at least MinCertificateValidityDays
+func checkTlsCertificate(entry tlsEntry, chain []*x509.Certificate, settings *Settings) error {
+	certificate := chain[0]
+
+	notCovered := []string{}
+	for _, host := range entry.hosts {
+		covered := false
+		for _, name := range certificate.DNSNames {
+			if wildcardMatches(name, host) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			notCovered = append(notCovered, host)
+		}
+	}
+	if len(notCovered) != 0 {
+		return fmt.Errorf("the certificate of secret %q does not cover these hosts: %s",
+			entry.secretName, strings.Join(notCovered, ", "))
+	}
+
+	currentTime := now()
+	if currentTime.Before(certificate.NotBefore) {
+		return fmt.Errorf("the certificate of secret %q is not valid before %s",
+			entry.secretName, certificate.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if !currentTime.Before(certificate.NotAfter) {
+		return fmt.Errorf("the certificate of secret %q expired on %s",
+			entry.secretName, certificate.NotAfter.UTC().Format(time.RFC3339))
+	}
+	validityWindow := time.Duration(settings.MinCertificateValidityDays) * 24 * time.Hour
+	if currentTime.Add(validityWindow).After(certificate.NotAfter) {
+		return fmt.Errorf("the certificate of secret %q expires on %s, within %d days",
+			entry.secretName, certificate.NotAfter.UTC().Format(time.RFC3339), settings.MinCertificateValidityDays)
+	}
+
+	return nil
+}
+
+// parseTrustedCABundle returns the pool of the trust anchors found
+// inside of the PEM bundle
+func parseTrustedCABundle(bundle string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(bundle)) {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return pool, nil
+}
+
+// The chain found inside of the secret must lead to one of the trust
+// anchors. The chain is verified offline, without fetching any missing
+// intermediate certificate.
+func checkCertificateChain(secretName string, chain []*x509.Certificate, roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("the certificate of secret %q is not issued by a trusted CA: %w", secretName, err)
+	}
+	return nil
+}
+
+// Every certificate found inside of the secret must use a strong enough
+// key and must not be signed with an insecure algorithm, like SHA-1
+func checkCertificateKeys(secretName string, chain []*x509.Certificate, settings *Settings) error {
+	minRSAKeySize, minECDSAKeySize := settings.MinRSAKeySize, settings.MinECDSAKeySize
+	if minRSAKeySize == 0 {
+		minRSAKeySize = defaultMinRSAKeySize
+	}
+	if minECDSAKeySize == 0 {
+		minECDSAKeySize = defaultMinECDSAKeySize
+	}
+
+	for index, certificate := range chain {
+		switch certificate.SignatureAlgorithm {
+		case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+			return fmt.Errorf("certificate #%d of secret %q is signed with the insecure %s algorithm",
+				index, secretName, certificate.SignatureAlgorithm)
+		}
+
+		switch key := certificate.PublicKey.(type) {
+		case *rsa.PublicKey:
+			if size := key.N.BitLen(); size < minRSAKeySize {
+				return fmt.Errorf("certificate #%d of secret %q has a %d bits RSA key, the minimum is %d bits",
+					index, secretName, size, minRSAKeySize)
+			}
+		case *ecdsa.PublicKey:
+			if size := key.Curve.Params().BitSize; size < minECDSAKeySize {
+				return fmt.Errorf("certificate #%d of secret %q has a %d bits ECDSA key, the minimum is %d bits",
+					index, secretName, size, minECDSAKeySize)
+			}
+		}
+	}
+	return nil
+}
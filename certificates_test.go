@@ -0,0 +1,271 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+var testTime = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         crypto.Signer
+	pem         []byte
+}
+
+// newTestCertificate creates a certificate from the template, signed by
+// the parent one. The certificate is self signed when parent is nil.
+func newTestCertificate(t *testing.T, template *x509.Certificate, key crypto.Signer, parent *testCertificate) testCertificate {
+	t.Helper()
+
+	if key == nil {
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("Unexpected error %+v", err)
+		}
+	}
+	if template.SerialNumber == nil {
+		template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	}
+
+	parentCertificate, parentKey := template, key
+	if parent != nil {
+		parentCertificate, parentKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCertificate, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("Unexpected error %+v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Unexpected error %+v", err)
+	}
+
+	return testCertificate{
+		certificate: certificate,
+		key:         key,
+		pem:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func newLeafTemplate(hosts []string, notBefore, notAfter time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:     pkix.Name{CommonName: hosts[0]},
+		DNSNames:    hosts,
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+}
+
+func newTlsSecret(chain ...testCertificate) map[string]interface{} {
+	data := []byte{}
+	for _, certificate := range chain {
+		data = append(data, certificate.pem...)
+	}
+	return map[string]interface{}{
+		"type": "kubernetes.io/tls",
+		"data": map[string]string{
+			"tls.crt": base64.StdEncoding.EncodeToString(data),
+		},
+	}
+}
+
+func useTestTime(t *testing.T) {
+	t.Helper()
+
+	originalNow := now
+	now = func() time.Time { return testTime }
+	t.Cleanup(func() {
+		now = originalNow
+	})
+}
+
+func TestTlsCertificateMustCoverTheTlsHosts(t *testing.T) {
+	useTestTime(t)
+	year := 365 * 24 * time.Hour
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): newTlsSecret(newTestCertificate(t,
+				newLeafTemplate([]string{"foo.bar.com"}, testTime.Add(-year), testTime.Add(year)), nil, nil)),
+			resourceKey("Secret", "team-a", "api-tls"): newTlsSecret(newTestCertificate(t,
+				newLeafTemplate([]string{"*.foo.com"}, testTime.Add(-year), testTime.Add(year)), nil, nil)),
+		},
+	})
+
+	response := validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSCertificates": true}`)
+	expectRejectedWith(t, response, `spec.tls[1]: the certificate of secret "api-tls" does not cover these hosts: api.bar.com`)
+}
+
+func TestWildcardTlsCertificateCoversTheTlsHosts(t *testing.T) {
+	useTestTime(t)
+	year := 365 * 24 * time.Hour
+	certificate := newTestCertificate(t,
+		newLeafTemplate([]string{"bar.com", "*.bar.com"}, testTime.Add(-year), testTime.Add(year)), nil, nil)
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): newTlsSecret(certificate),
+			resourceKey("Secret", "team-a", "api-tls"): newTlsSecret(certificate),
+		},
+	})
+
+	response := validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSCertificates": true, "minCertificateValidityDays": 30}`)
+	expectAccepted(t, response)
+}
+
+func TestExpiredTlsCertificatesAreRejected(t *testing.T) {
+	useTestTime(t)
+	day := 24 * time.Hour
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): newTlsSecret(newTestCertificate(t,
+				newLeafTemplate([]string{"foo.bar.com"}, testTime.Add(-90*day), testTime.Add(-day)), nil, nil)),
+			resourceKey("Secret", "team-a", "api-tls"): newTlsSecret(newTestCertificate(t,
+				newLeafTemplate([]string{"api.bar.com"}, testTime.Add(-90*day), testTime.Add(10*day)), nil, nil)),
+		},
+	})
+
+	response := validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSCertificates": true}`)
+	expectRejectedWith(t, response, `spec.tls[0]: the certificate of secret "foo-tls" expired on 2024-02-29T12:00:00Z`)
+
+	response = validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSCertificates": true, "minCertificateValidityDays": 30}`)
+	expectRejectedWith(t, response, `spec.tls[1]: the certificate of secret "api-tls" expires on 2024-03-11T12:00:00Z, within 30 days`)
+}
+
+func TestTlsSecretWithoutCertificateIsRejected(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): corev1.Secret{Type: "kubernetes.io/tls"},
+			resourceKey("Secret", "team-a", "api-tls"): corev1.Secret{Type: "kubernetes.io/tls"},
+		},
+	})
+
+	response := validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSCertificates": true}`)
+	expectRejectedWith(t, response, `spec.tls[0]: secret "foo-tls": the tls.crt key is missing`)
+}
+
+func newCATemplate(name string, notBefore, notAfter time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+}
+
+func trustedCASettings(t *testing.T, ca testCertificate) string {
+	t.Helper()
+
+	settings, err := json.Marshal(map[string]interface{}{
+		"trustedCABundle": string(ca.pem),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %+v", err)
+	}
+	return string(settings)
+}
+
+func TestTlsCertificateChainIsVerified(t *testing.T) {
+	useTestTime(t)
+	year := 365 * 24 * time.Hour
+	rootCA := newTestCertificate(t, newCATemplate("Root CA", testTime.Add(-year), testTime.Add(10*year)), nil, nil)
+	intermediateCA := newTestCertificate(t, newCATemplate("Intermediate CA", testTime.Add(-year), testTime.Add(5*year)), nil, &rootCA)
+	otherCA := newTestCertificate(t, newCATemplate("Other CA", testTime.Add(-year), testTime.Add(10*year)), nil, nil)
+
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): newTlsSecret(
+				newTestCertificate(t, newLeafTemplate([]string{"foo.bar.com"}, testTime.Add(-year), testTime.Add(year)), nil, &intermediateCA),
+				intermediateCA),
+			resourceKey("Secret", "team-a", "api-tls"): newTlsSecret(
+				newTestCertificate(t, newLeafTemplate([]string{"api.bar.com"}, testTime.Add(-year), testTime.Add(year)), nil, &rootCA)),
+		},
+	})
+
+	response := validateFixture(t, "test_data/tls-secrets.json", trustedCASettings(t, rootCA))
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/tls-secrets.json", trustedCASettings(t, otherCA))
+	expectRejectedWith(t, response, `spec.tls[0]: the certificate of secret "foo-tls" is not issued by a trusted CA`)
+}
+
+func TestTlsCertificateChainWithoutIntermediateIsRejected(t *testing.T) {
+	useTestTime(t)
+	year := 365 * 24 * time.Hour
+	rootCA := newTestCertificate(t, newCATemplate("Root CA", testTime.Add(-year), testTime.Add(10*year)), nil, nil)
+	intermediateCA := newTestCertificate(t, newCATemplate("Intermediate CA", testTime.Add(-year), testTime.Add(5*year)), nil, &rootCA)
+	certificate := newTestCertificate(t, newLeafTemplate([]string{"*.bar.com"}, testTime.Add(-year), testTime.Add(year)), nil, &intermediateCA)
+
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): newTlsSecret(certificate),
+			resourceKey("Secret", "team-a", "api-tls"): newTlsSecret(certificate),
+		},
+	})
+
+	response := validateFixture(t, "test_data/tls-secrets.json", trustedCASettings(t, rootCA))
+	expectRejectedWith(t, response, `the certificate of secret "foo-tls" is not issued by a trusted CA`)
+}
+
+func TestWeakTlsCertificateKeysAreRejected(t *testing.T) {
+	useTestTime(t)
+	year := 365 * 24 * time.Hour
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Unexpected error %+v", err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error %+v", err)
+	}
+
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): newTlsSecret(newTestCertificate(t,
+				newLeafTemplate([]string{"foo.bar.com"}, testTime.Add(-year), testTime.Add(year)), rsaKey, nil)),
+			resourceKey("Secret", "team-a", "api-tls"): newTlsSecret(newTestCertificate(t,
+				newLeafTemplate([]string{"api.bar.com"}, testTime.Add(-year), testTime.Add(year)), ecdsaKey, nil)),
+		},
+	})
+
+	// The key policy is not enforced by the certificate verification alone
+	response := validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSCertificates": true}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSCertificates": true, "minRSAKeySize": 2048}`)
+	expectRejectedWith(t, response, `spec.tls[0]: certificate #0 of secret "foo-tls" has a 1024 bits RSA key, the minimum is 2048 bits`)
+	expectRejectedWith(t, response, `spec.tls[1]: certificate #0 of secret "api-tls" has a 224 bits ECDSA key, the minimum is 256 bits`)
+
+	response = validateFixture(t, "test_data/tls-secrets.json", `{"minRSAKeySize": 1024, "minECDSAKeySize": 224}`)
+	expectAccepted(t, response)
+}
+
+func TestSha1SignedTlsCertificatesAreRejected(t *testing.T) {
+	year := 365 * 24 * time.Hour
+	certificate := newTestCertificate(t, newLeafTemplate([]string{"foo.bar.com"}, testTime.Add(-year), testTime.Add(year)), nil, nil)
+	certificate.certificate.SignatureAlgorithm = x509.ECDSAWithSHA1
+
+	settings := Settings{}
+	err := checkCertificateKeys("foo-tls", []*x509.Certificate{certificate.certificate}, &settings)
+	if err == nil || err.Error() != `certificate #0 of secret "foo-tls" is signed with the insecure ECDSA-SHA1 algorithm` {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubewarden/gjson"
+)
+
+// hostPath identifies the traffic routed by a path of an Ingress rule
+type hostPath struct {
+	host string
+	path string
+}
+
+func parseHostPaths(rules gjson.Result) map[hostPath]string {
+	hostPaths := map[hostPath]string{}
+	forEachPath(rules, func(ruleIndex, pathIndex int, rule, path gjson.Result) {
+		key := hostPath{
+			host: strings.ToLower(rule.Get("host").String()),
+			path: path.Get("path").String(),
+		}
+		if _, found := hostPaths[key]; !found {
+			hostPaths[key] = pathField(ruleIndex, pathIndex)
+		}
+	})
+	return hostPaths
+}
+
+// collisionAllowed reports whether the two namespaces are allowed to
+// share the same host and path
+func collisionAllowed(namespace, otherNamespace string, settings *Settings) bool {
+	for _, pair := range settings.AllowedCollisionNamespaces {
+		if (pair[0] == namespace && pair[1] == otherNamespace) ||
+			(pair[0] == otherNamespace && pair[1] == namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// When DenyCrossNamespaceCollisions is enabled, the host and path pairs
+// of the Ingress must not be used by the Ingresses of other namespaces,
+// unless the two namespaces are listed inside of
+// AllowedCollisionNamespaces.
+// This requires the policy to be context aware.
+func checkHostPathCollisions(payload []byte, namespace string, settings *Settings) error {
+	if !settings.DenyCrossNamespaceCollisions {
+		return nil
+	}
+
+	hostPaths := parseHostPaths(gjson.GetBytes(payload, "request.object.spec.rules"))
+	if len(hostPaths) == 0 {
+		return nil
+	}
+
+	ingresses, err := listIngresses()
+	if err != nil {
+		return fmt.Errorf("cannot list the existing ingresses: %w", err)
+	}
+
+	violations := []string{}
+	for _, ingress := range gjson.GetBytes(ingresses, "items").Array() {
+		otherNamespace := ingress.Get("metadata.namespace").String()
+		if otherNamespace == namespace || collisionAllowed(namespace, otherNamespace, settings) {
+			continue
+		}
+
+		otherName := ingress.Get("metadata.name").String()
+		forEachPath(ingress.Get("spec.rules"), func(_, _ int, rule, path gjson.Result) {
+			key := hostPath{
+				host: strings.ToLower(rule.Get("host").String()),
+				path: path.Get("path").String(),
+			}
+			field, found := hostPaths[key]
+			if !found {
+				return
+			}
+			violations = append(violations,
+				fmt.Sprintf("%s: host %q and path %q are already used by ingress %s/%s",
+					field, key.host, key.path, otherNamespace, otherName))
+			delete(hostPaths, key)
+		})
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+)
+
+func ingressObject(namespace, name string, rules ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "Ingress",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"rules": rules,
+		},
+	}
+}
+
+func ingressRule(host string, paths ...string) map[string]interface{} {
+	httpPaths := []interface{}{}
+	for _, path := range paths {
+		httpPaths = append(httpPaths, map[string]interface{}{
+			"path":     path,
+			"pathType": "Prefix",
+			"backend": map[string]interface{}{
+				"service": map[string]interface{}{
+					"name": "service",
+					"port": map[string]interface{}{"number": 443},
+				},
+			},
+		})
+	}
+	return map[string]interface{}{
+		"host": host,
+		"http": map[string]interface{}{"paths": httpPaths},
+	}
+}
+
+func TestCrossNamespaceCollisionsAreRejected(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Ingress", "team-a", "web"): ingressObject("team-a", "web",
+				ingressRule("web.team-a.corp.example", "/")),
+			resourceKey("Ingress", "team-b", "api"): ingressObject("team-b", "api",
+				ingressRule("api.team-b.corp.example", "/", "/v1")),
+			resourceKey("Ingress", "team-c", "api"): ingressObject("team-c", "api",
+				ingressRule("api.team-b.corp.example", "/v2")),
+		},
+	})
+
+	response := validateFixture(t, "test_data/team-a-hosts.json", `{"denyCrossNamespaceCollisions": true}`)
+	expectRejectedWith(t, response, `spec.rules[1].http.paths[0]: host "api.team-b.corp.example" and path "/" are already used by ingress team-b/api`)
+
+	response = validateFixture(t, "test_data/team-a-hosts.json", `{"denyCrossNamespaceCollisions": true, "allowedCollisionNamespaces": [["team-b", "team-a"]]}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/team-a-hosts.json", `{}`)
+	expectAccepted(t, response)
+}
+
+func TestSameNamespaceIngressesDoNotCollide(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Ingress", "team-a", "team-a-ingress"): ingressObject("team-a", "team-a-ingress",
+				ingressRule("web.team-a.corp.example", "/"),
+				ingressRule("api.team-b.corp.example", "/")),
+		},
+	})
+
+	response := validateFixture(t, "test_data/team-a-hosts.json", `{"denyCrossNamespaceCollisions": true}`)
+	expectAccepted(t, response)
+}
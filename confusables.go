@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Hosts whose skeleton is within this edit distance from the skeleton
+// of a protected domain are considered confusable with it
+const maxConfusableEditDistance = 1
+
+// confusableRunes maps the characters that look like an ASCII letter or
+// digit to it. This is a subset of the Unicode confusables list
+// (https://www.unicode.org/Public/security/latest/confusables.txt),
+// restricted to the characters that can be used inside of
+// internationalized domain names, plus the Latin letters with
+// diacritics.
+var confusableRunes = map[rune]rune{
+	// ASCII characters that are easy to mix up
+	'0': 'o', '1': 'l', 'i': 'l', '|': 'l',
+
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'ё': 'e', 'һ': 'h',
+	'і': 'l', 'ї': 'l', 'ӏ': 'l', 'ј': 'j', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'ԛ': 'q', 'г': 'r', 'ѕ': 's', 'т': 't', 'ц': 'u',
+	'ѵ': 'v', 'ԝ': 'w', 'х': 'x', 'у': 'y', 'ү': 'y',
+
+	// Greek
+	'α': 'a', 'β': 'b', 'ϲ': 'c', 'ε': 'e', 'η': 'n', 'ι': 'l', 'κ': 'k',
+	'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'γ': 'y',
+	'ω': 'w',
+
+	// Latin letters with diacritics and other Latin look-alikes
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ā': 'a',
+	'ă': 'a', 'ą': 'a', 'ɑ': 'a',
+	'ç': 'c', 'ć': 'c', 'ĉ': 'c', 'ċ': 'c', 'č': 'c',
+	'ď': 'd', 'đ': 'd',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ē': 'e', 'ĕ': 'e', 'ė': 'e',
+	'ę': 'e', 'ě': 'e',
+	'ĝ': 'g', 'ğ': 'g', 'ġ': 'g', 'ģ': 'g', 'ɡ': 'g',
+	'ĥ': 'h', 'ħ': 'h',
+	'ì': 'l', 'í': 'l', 'î': 'l', 'ï': 'l', 'ĩ': 'l', 'ī': 'l', 'ĭ': 'l',
+	'į': 'l', 'ı': 'l', 'ĺ': 'l', 'ļ': 'l', 'ľ': 'l', 'ŀ': 'l', 'ł': 'l',
+	'ĵ': 'j', 'ķ': 'k',
+	'ñ': 'n', 'ń': 'n', 'ņ': 'n', 'ň': 'n',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o', 'ō': 'o',
+	'ŏ': 'o', 'ő': 'o',
+	'ŕ': 'r', 'ŗ': 'r', 'ř': 'r',
+	'ś': 's', 'ŝ': 's', 'ş': 's', 'š': 's',
+	'ţ': 't', 'ť': 't', 'ŧ': 't',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ũ': 'u', 'ū': 'u', 'ŭ': 'u',
+	'ů': 'u', 'ű': 'u', 'ų': 'u',
+	'ŵ': 'w',
+	'ý': 'y', 'ÿ': 'y', 'ŷ': 'y',
+	'ź': 'z', 'ż': 'z', 'ž': 'z',
+}
+
+// confusableSequences maps the sequences of ASCII letters that look like
+// a single letter to it
+var confusableSequences = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// confusableSkeleton returns the skeleton of the name: names that look
+// alike have the same skeleton, like `exаmple.com`, written with a
+// Cyrillic `а`, and `example.com`
+func confusableSkeleton(name string) string {
+	var skeleton strings.Builder
+	for _, char := range strings.ToLower(name) {
+		if replacement, found := confusableRunes[char]; found {
+			char = replacement
+		}
+		skeleton.WriteRune(char)
+	}
+	return confusableSequences.Replace(skeleton.String())
+}
+
+// editDistance returns the optimal string alignment distance between
+// the two strings: the number of insertions, deletions, substitutions
+// and transpositions of adjacent characters needed to turn one string
+// into the other
+func editDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	beforePrevious := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && source[i-1] == target[j-2] && source[i-2] == target[j-1] {
+				current[j] = min(current[j], beforePrevious[j-2]+1)
+			}
+		}
+		beforePrevious, previous, current = previous, current, beforePrevious
+	}
+	return previous[len(target)]
+}
+
+// confusableWith reports whether the host looks like the protected
+// domain without being under it. The trailing labels of the host are
+// compared with the domain, once both have been decoded from punycode
+// and reduced to their skeleton.
+func confusableWith(host, domain string) bool {
+	if hostUnderDomain(host, domain) {
+		return false
+	}
+
+	decodedHost, err := decodeHostname(strings.ToLower(strings.TrimPrefix(host, "*.")))
+	if err != nil {
+		return false
+	}
+	decodedDomain, err := decodeHostname(strings.ToLower(domain))
+	if err != nil {
+		return false
+	}
+	if hostUnderDomain(decodedHost, decodedDomain) {
+		return false
+	}
+
+	hostLabels := strings.Split(decodedHost, ".")
+	domainLabels := strings.Split(decodedDomain, ".")
+	if len(hostLabels) < len(domainLabels) {
+		return false
+	}
+	candidate := strings.Join(hostLabels[len(hostLabels)-len(domainLabels):], ".")
+
+	return editDistance(confusableSkeleton(candidate), confusableSkeleton(decodedDomain)) <= maxConfusableEditDistance
+}
+
+// The hosts that look like one of the ProtectedDomains, without being
+// under it, are rejected unless they are listed inside of
+// ProtectedDomainsExceptions
+func checkProtectedDomains(hosts []ingressHost, settings *Settings) error {
+	if len(settings.ProtectedDomains) == 0 {
+		return nil
+	}
+
+	violations := []string{}
+	for _, host := range hosts {
+		if settings.protectedDomainsException(host.name) {
+			continue
+		}
+		for _, domain := range settings.ProtectedDomains {
+			if confusableWith(host.name, domain) {
+				violations = append(violations, fmt.Sprintf("host %s looks like the protected domain %q", host, domain))
+				break
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
+
+func (s *Settings) protectedDomainsException(host string) bool {
+	for _, exception := range s.ProtectedDomainsExceptions {
+		if strings.EqualFold(exception, host) {
+			return true
+		}
+	}
+	return false
+}
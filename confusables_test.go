@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	distances := []struct {
+		a, b     string
+		distance int
+	}{
+		{"example", "example", 0},
+		{"example", "exampel", 1},
+		{"example", "examples", 1},
+		{"example", "exmple", 1},
+		{"example", "exanple", 1},
+		{"example", "elpmaxe", 4},
+		{"", "abc", 3},
+	}
+	for _, test := range distances {
+		if distance := editDistance(test.a, test.b); distance != test.distance {
+			t.Errorf("Distance between %s and %s: expected %d, got %d", test.a, test.b, test.distance, distance)
+		}
+	}
+}
+
+func TestConfusableWith(t *testing.T) {
+	confusable := []string{
+		"xn--exmple-4nf.com",
+		"xn--exmple-cua.com",
+		"login.examp1e.com",
+		"*.exarnple.com",
+		"exampel.com",
+		"example.co",
+		"Example.corn",
+	}
+	for _, host := range confusable {
+		if !confusableWith(host, "example.com") {
+			t.Errorf("%s should be confusable with example.com", host)
+		}
+	}
+
+	notConfusable := []string{
+		"example.com",
+		"api.Example.com",
+		"*.example.com",
+		"example.org",
+		"another.com",
+		"com",
+		"xn--99999999999.com",
+	}
+	for _, host := range notConfusable {
+		if confusableWith(host, "example.com") {
+			t.Errorf("%s should not be confusable with example.com", host)
+		}
+	}
+}
+
+func TestProtectedDomains(t *testing.T) {
+	response := validateFixture(t, "test_data/protected-domains.json", `{"protectedDomains": ["example.com"]}`)
+	expectRejectedWith(t, response,
+		`host "login.xn--exmple-4nf.com" (spec.rules[1].host) looks like the protected domain "example.com"; `+
+			`host "examp1e.com" (spec.rules[2].host) looks like the protected domain "example.com"; `+
+			`host "exampel.com" (spec.tls[0].hosts[0]) looks like the protected domain "example.com"`)
+
+	response = validateFixture(t, "test_data/protected-domains.json", `{
+		"protectedDomains": ["example.com"],
+		"protectedDomainsExceptions": ["login.xn--exmple-4nf.com", "examp1e.com", "exampel.com"]
+	}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/protected-domains.json", `{"protectedDomains": ["kubewarden.io"]}`)
+	expectAccepted(t, response)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/kubernetes"
+)
+
+// host gives access to the capabilities offered by the policy host,
+// like the lookup of Kubernetes resources. The tests replace it with a
+// fake one.
+var host = capabilities.NewHost()
+
+func getSecret(namespace, name string) (*corev1.Secret, error) {
+	response, err := kubernetes.GetResource(&host, kubernetes.GetResourceRequest{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Name:       name,
+		Namespace:  &namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secret := corev1.Secret{}
+	if err := json.Unmarshal(response, &secret); err != nil {
+		return nil, fmt.Errorf("cannot decode secret %s/%s: %w", namespace, name, err)
+	}
+	return &secret, nil
+}
+
+func getNamespace(name string) (*corev1.Namespace, error) {
+	response, err := kubernetes.GetResource(&host, kubernetes.GetResourceRequest{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Name:       name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := corev1.Namespace{}
+	if err := json.Unmarshal(response, &namespace); err != nil {
+		return nil, fmt.Errorf("cannot decode namespace %s: %w", name, err)
+	}
+	return &namespace, nil
+}
+
+// listIngresses returns the list of all the Ingresses of the cluster
+func listIngresses() ([]byte, error) {
+	return kubernetes.ListResources(&host, kubernetes.ListAllResourcesRequest{
+		APIVersion: "networking.k8s.io/v1",
+		Kind:       "Ingress",
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities/kubernetes"
+)
+
+// fakeWapcClient answers the Kubernetes lookups of the policy using
+// the resources it has been created with
+type fakeWapcClient struct {
+	// resources indexed by "kind/namespace/name"
+	resources map[string]interface{}
+}
+
+func resourceKey(kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+func (c *fakeWapcClient) HostCall(binding, namespace, operation string, payload []byte) ([]byte, error) {
+	if binding != "kubewarden" || namespace != "kubernetes" {
+		return nil, fmt.Errorf("unexpected host call %s/%s/%s", binding, namespace, operation)
+	}
+
+	switch operation {
+	case "get_resource":
+		req := kubernetes.GetResourceRequest{}
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		resourceNamespace := ""
+		if req.Namespace != nil {
+			resourceNamespace = *req.Namespace
+		}
+		resource, found := c.resources[resourceKey(req.Kind, resourceNamespace, req.Name)]
+		if !found {
+			return nil, fmt.Errorf("%s %s/%s not found", req.Kind, resourceNamespace, req.Name)
+		}
+		return json.Marshal(resource)
+	case "list_resources_all":
+		req := kubernetes.ListAllResourcesRequest{}
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		keys := []string{}
+		for key := range c.resources {
+			if strings.HasPrefix(key, req.Kind+"/") {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		items := []interface{}{}
+		for _, key := range keys {
+			items = append(items, c.resources[key])
+		}
+		return json.Marshal(map[string]interface{}{"items": items})
+	default:
+		return nil, fmt.Errorf("unexpected operation %s", operation)
+	}
+}
+
+// useFakeHost replaces the policy host with a fake one for the
+// duration of the test
+func useFakeHost(t *testing.T, client *fakeWapcClient) {
+	t.Helper()
+
+	originalHost := host
+	host = capabilities.Host{Client: client}
+	t.Cleanup(func() {
+		host = originalHost
+	})
+}
@@ -34,7 +34,31 @@
   # request rejected
   [ "$status" -eq 0 ]
   [ $(expr "$output" : '.*allowed.*false') -ne 0 ]
-  [ $(expr "$output" : '.*these ports are explicitly denied: Set{3000}.*') -ne 0 ]
+  [ $(expr "$output" : '.*these ports are explicitly denied: 3000.*') -ne 0 ]
+}
+
+@test "reject because port inside of a denied range is used" {
+  run kwctl run annotated-policy.wasm -r test_data/ingress-wildcard.json --settings-json '{"denyPorts": ["2000-3999"]}'
+
+  # this prints the output when one the checks below fails
+  echo "output = ${output}"
+
+  # request rejected
+  [ "$status" -eq 0 ]
+  [ $(expr "$output" : '.*allowed.*false') -ne 0 ]
+  [ $(expr "$output" : '.*these ports are explicitly denied: 3000 (range 2000-3999).*') -ne 0 ]
+}
+
+@test "reject because wildcard hosts are denied" {
+  run kwctl run annotated-policy.wasm -r test_data/ingress-wildcard.json --settings-json '{"denyWildcardHosts": true}'
+
+  # this prints the output when one the checks below fails
+  echo "output = ${output}"
+
+  # request rejected
+  [ "$status" -eq 0 ]
+  [ $(expr "$output" : '.*allowed.*false') -ne 0 ]
+  [ $(expr "$output" : '.*wildcard hosts are not allowed.*') -ne 0 ]
 }
 
 @test "reject because invalid settings" {
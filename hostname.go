@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	maxHostnameLength = 253
+	maxLabelLength    = 63
+	punycodePrefix    = "xn--"
+)
+
+// validateHostname checks the host is a valid RFC 1123 DNS name, as
+// expected by Kubernetes. Wildcard hosts are allowed, as long as the
+// wildcard is the whole leftmost label. Internationalized names must be
+// punycode encoded.
+func validateHostname(host string) error {
+	if net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return errors.New("IP addresses are not allowed")
+	}
+	if strings.HasSuffix(host, ".") {
+		return errors.New("trailing dots are not allowed")
+	}
+	if len(host) > maxHostnameLength {
+		return fmt.Errorf("it is longer than %d characters", maxHostnameLength)
+	}
+	for _, char := range host {
+		if char > 0x7f {
+			return errors.New("non ASCII names must be punycode encoded")
+		}
+	}
+	if strings.ToLower(host) != host {
+		return errors.New("it must be lowercase")
+	}
+
+	name := strings.TrimPrefix(host, "*.")
+	for _, label := range strings.Split(name, ".") {
+		if err := validateLabel(label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateLabel(label string) error {
+	if label == "" {
+		return errors.New("empty labels are not allowed")
+	}
+	if len(label) > maxLabelLength {
+		return fmt.Errorf("label %q is longer than %d characters", label, maxLabelLength)
+	}
+	for _, char := range label {
+		if !(char >= 'a' && char <= 'z') && !(char >= '0' && char <= '9') && char != '-' {
+			return fmt.Errorf("label %q has the invalid character %q", label, char)
+		}
+	}
+	if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return fmt.Errorf("label %q cannot start or end with a dash", label)
+	}
+	if strings.HasPrefix(label, punycodePrefix) {
+		if _, err := decodeLabel(label); err != nil {
+			return fmt.Errorf("label %q is not valid punycode", label)
+		}
+	}
+	return nil
+}
+
+// When ValidateHostnames is enabled, each host must be a valid DNS name
+// and hosts must not differ just by their case
+func checkHostnames(hosts []ingressHost, settings *Settings) error {
+	if !settings.ValidateHostnames {
+		return nil
+	}
+
+	violations := []string{}
+	seen := map[string]ingressHost{}
+	for _, host := range hosts {
+		if err := validateHostname(host.name); err != nil {
+			violations = append(violations, fmt.Sprintf("%s: host %q is invalid, %s", host.field, host.name, err))
+		}
+
+		lowercaseName := strings.ToLower(host.name)
+		if other, found := seen[lowercaseName]; found {
+			if other.name != host.name {
+				violations = append(violations,
+					fmt.Sprintf("%s: host %q differs only by case from host %s", host.field, host.name, other))
+			}
+			continue
+		}
+		seen[lowercaseName] = host
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
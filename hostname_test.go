@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestValidHostnames(t *testing.T) {
+	valid := []string{
+		"foo.bar.com",
+		"*.bar.com",
+		"a-b.c0.example",
+		"xn--bcher-kva.example",
+		"localhost",
+	}
+	for _, host := range valid {
+		if err := validateHostname(host); err != nil {
+			t.Errorf("Host %s should be valid: %+v", host, err)
+		}
+	}
+}
+
+func TestInvalidHostnames(t *testing.T) {
+	invalid := map[string]string{
+		"10.0.0.1":               "IP addresses are not allowed",
+		"[::1]":                  "IP addresses are not allowed",
+		"foo.bar.com.":           "trailing dots are not allowed",
+		"Foo.bar.com":            "it must be lowercase",
+		"bücher.example":         "non ASCII names must be punycode encoded",
+		"foo..com":               "empty labels are not allowed",
+		"-foo.com":               `label "-foo" cannot start or end with a dash`,
+		"foo_bar.com":            `label "foo_bar" has the invalid character '_'`,
+		"*.*.bar.com":            `label "*" has the invalid character '*'`,
+		"foo.*.com":              `label "*" has the invalid character '*'`,
+		"xn--abc-.example":       `label "xn--abc-" cannot start or end with a dash`,
+		"xn--99999999999.com":    `label "xn--99999999999" is not valid punycode`,
+		"xn--a.com":              `label "xn--a" is not valid punycode`,
+		"a" + longLabel + ".com": `label "a` + longLabel + `" is longer than 63 characters`,
+	}
+	for host, expected := range invalid {
+		err := validateHostname(host)
+		if err == nil {
+			t.Errorf("Host %s should be invalid", host)
+			continue
+		}
+		if err.Error() != expected {
+			t.Errorf("Host %s: expected error %q, got %q", host, expected, err)
+		}
+	}
+}
+
+const longLabel = "123456789012345678901234567890123456789012345678901234567890123"
+
+func TestHostnamesAreValidated(t *testing.T) {
+	response := validateFixture(t, "test_data/invalid-hosts.json", `{}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/invalid-hosts.json", `{"validateHostnames": true}`)
+	expectRejectedWith(t, response,
+		`spec.rules[0].host: host "Foo.bar.com" is invalid, it must be lowercase; `+
+			`spec.rules[1].host: host "foo.bar.com" differs only by case from host "Foo.bar.com" (spec.rules[0].host); `+
+			`spec.rules[2].host: host "10.0.0.1" is invalid, IP addresses are not allowed; `+
+			`spec.tls[0].hosts[0]: host "foo.bar.com" differs only by case from host "Foo.bar.com" (spec.rules[0].host); `+
+			`spec.tls[0].hosts[1]: host "bücher.bar.com." is invalid, trailing dots are not allowed`)
+}
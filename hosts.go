@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kubewarden/gjson"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+	"github.com/tidwall/match"
+)
+
+// ingressHost is a host defined inside of an Ingress, together with
+// the path of the field defining it
+type ingressHost struct {
+	name  string
+	field string
+	// Whether the host comes from spec.tls instead of spec.rules
+	tls bool
+}
+
+func (h ingressHost) String() string {
+	return fmt.Sprintf("%q (%s)", h.name, h.field)
+}
+
+// parseHosts returns the hosts of spec.rules and spec.tls. The rules
+// without a host are skipped.
+func parseHosts(payload []byte) []ingressHost {
+	hosts := []ingressHost{}
+
+	data := gjson.GetManyBytes(
+		payload,
+		"request.object.spec.rules",
+		"request.object.spec.tls")
+
+	for ruleIndex, rule := range data[0].Array() {
+		host := rule.Get("host")
+		if !host.Exists() || host.String() == "" {
+			continue
+		}
+		hosts = append(hosts, ingressHost{
+			name:  host.String(),
+			field: fmt.Sprintf("spec.rules[%d].host", ruleIndex),
+		})
+	}
+
+	for tlsIndex, entry := range data[1].Array() {
+		for hostIndex, host := range entry.Get("hosts").Array() {
+			hosts = append(hosts, ingressHost{
+				name:  host.String(),
+				field: fmt.Sprintf("spec.tls[%d].hosts[%d]", tlsIndex, hostIndex),
+				tls:   true,
+			})
+		}
+	}
+
+	return hosts
+}
+
+// catchAllFields returns the fields of the Ingress matching all the
+// hosts handled by the ingress controller: the rules without a host and
+// spec.defaultBackend
+func catchAllFields(payload []byte) []string {
+	fields := []string{}
+
+	data := gjson.GetManyBytes(
+		payload,
+		"request.object.spec.rules",
+		"request.object.spec.defaultBackend")
+
+	for ruleIndex, rule := range data[0].Array() {
+		if rule.Get("host").String() == "" {
+			fields = append(fields, fmt.Sprintf("spec.rules[%d]", ruleIndex))
+		}
+	}
+	if data[1].Exists() {
+		fields = append(fields, "spec.defaultBackend")
+	}
+
+	return fields
+}
+
+// globMatches reports whether the host matches the glob pattern. The
+// `*` character matches any sequence of characters, dots included, the
+// `?` character matches any single character.
+// Host names are compared case insensitively.
+func globMatches(pattern, host string) bool {
+	return match.Match(strings.ToLower(host), strings.ToLower(pattern))
+}
+
+func globMatchesAny(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if globMatches(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Each host must not match any of the DeniedHosts patterns. When
+// AllowedHosts is not empty, each host must match one of its patterns
+// and the catch-all fields, which match the hosts that are not allowed
+// too, are rejected.
+func checkAllowedHosts(hosts []ingressHost, catchAll []string, settings *Settings) error {
+	if len(settings.AllowedHosts) == 0 && len(settings.DeniedHosts) == 0 {
+		return nil
+	}
+
+	violations := []string{}
+	for _, host := range hosts {
+		if globMatchesAny(settings.DeniedHosts, host.name) {
+			violations = append(violations, fmt.Sprintf("host %s is denied", host))
+			continue
+		}
+		if len(settings.AllowedHosts) != 0 && !globMatchesAny(settings.AllowedHosts, host.name) {
+			violations = append(violations, fmt.Sprintf("host %s is not allowed", host))
+		}
+	}
+	if len(settings.AllowedHosts) != 0 {
+		for _, field := range catchAll {
+			violations = append(violations, fmt.Sprintf("%s matches all the hosts, not only the allowed ones", field))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
+
+// hostUnderDomain reports whether the host is the domain itself or one
+// of its subdomains. Wildcard hosts are subdomains of the domain their
+// suffix belongs to.
+func hostUnderDomain(host, domain string) bool {
+	host = strings.ToLower(host)
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func hostUnderAnyDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if hostUnderDomain(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceDomains returns the domains owned by the namespace, merging
+// the entries of all the NamespaceDomains keys matching it
+func namespaceDomains(namespace string, settings *Settings) []string {
+	domains := []string{}
+	for pattern, patternDomains := range settings.NamespaceDomains {
+		if match.Match(namespace, pattern) {
+			domains = append(domains, patternDomains...)
+		}
+	}
+	return domains
+}
+
+// When NamespaceDomains is provided, each host must be under one of the
+// domains owned by the namespace of the Ingress. The catch-all fields
+// claim the domains of the other namespaces too, hence they are
+// rejected.
+func checkNamespaceDomains(hosts []ingressHost, catchAll []string, namespace string, settings *Settings) error {
+	if len(settings.NamespaceDomains) == 0 {
+		return nil
+	}
+
+	if len(catchAll) != 0 {
+		return fmt.Errorf("these fields match all the hosts, including the ones outside of the domains owned by namespace %q: %s",
+			namespace, strings.Join(catchAll, ", "))
+	}
+
+	domains := namespaceDomains(namespace, settings)
+	violations := []string{}
+	for _, host := range hosts {
+		if !hostUnderAnyDomain(host.name, domains) {
+			violations = append(violations, fmt.Sprintf("host %s", host))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	if len(domains) == 0 {
+		return fmt.Errorf("namespace %q does not own any domain, these hosts cannot be used: %s",
+			namespace, strings.Join(violations, ", "))
+	}
+	return fmt.Errorf("these hosts are outside of the domains owned by namespace %q (%s): %s",
+		namespace, strings.Join(domains, ", "), strings.Join(violations, ", "))
+}
+
+// When NamespaceAllowedHostsAnnotation is provided, each host must match
+// one of the glob patterns listed inside of that annotation of the
+// Namespace of the Ingress. The annotation holds a comma separated list.
+// The catch-all fields match the hosts that are not listed too, hence
+// they are rejected. This requires the policy to be context aware.
+func checkNamespaceAllowedHosts(hosts []ingressHost, catchAll []string, namespace string, settings *Settings) error {
+	annotation := settings.NamespaceAllowedHostsAnnotation
+	if annotation == "" {
+		return nil
+	}
+	if len(catchAll) != 0 {
+		return fmt.Errorf("these fields match all the hosts, including the ones not allowed by the %s annotation of namespace %q: %s",
+			annotation, namespace, strings.Join(catchAll, ", "))
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	namespaceObj, err := getNamespace(namespace)
+	if err != nil {
+		return fmt.Errorf("cannot find namespace %q: %w", namespace, err)
+	}
+
+	patterns := []string{}
+	if namespaceObj.Metadata != nil {
+		for _, pattern := range strings.Split(namespaceObj.Metadata.Annotations[annotation], ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	if len(patterns) == 0 {
+		return fmt.Errorf("namespace %q does not have the %s annotation, no host can be used", namespace, annotation)
+	}
+
+	violations := []string{}
+	for _, host := range hosts {
+		if !globMatchesAny(patterns, host.name) {
+			violations = append(violations, fmt.Sprintf("host %s", host))
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("these hosts are not allowed by the %s annotation of namespace %q: %s",
+		annotation, namespace, strings.Join(violations, ", "))
+}
+
+// When RequireHost is enabled, every rule must have a host. A rule
+// without a host matches all the hosts handled by the controller.
+func checkRequireHost(payload []byte, settings *Settings) error {
+	if !settings.RequireHost {
+		return nil
+	}
+
+	hostlessRules := []string{}
+	for ruleIndex, rule := range gjson.GetBytes(payload, "request.object.spec.rules").Array() {
+		if rule.Get("host").String() == "" {
+			hostlessRules = append(hostlessRules, fmt.Sprintf("spec.rules[%d]", ruleIndex))
+		}
+	}
+	if len(hostlessRules) == 0 {
+		return nil
+	}
+	return fmt.Errorf("rules without a host are not allowed: %s", strings.Join(hostlessRules, ", "))
+}
+
+func isWildcardHost(host string) bool {
+	return strings.Contains(host, "*")
+}
+
+// When DenyWildcardHosts is enabled, the rules cannot use wildcard
+// hosts, unless the Ingress is inside of one of the
+// WildcardHostsAllowedNamespaces or the requester belongs to one of the
+// WildcardHostsAllowedGroups
+func checkWildcardHosts(hosts []ingressHost, request *kubewarden_protocol.KubernetesAdmissionRequest, settings *Settings) error {
+	if !settings.DenyWildcardHosts {
+		return nil
+	}
+
+	for _, pattern := range settings.WildcardHostsAllowedNamespaces {
+		if match.Match(request.Namespace, pattern) {
+			return nil
+		}
+	}
+	for _, group := range request.UserInfo.Groups {
+		if slices.Contains(settings.WildcardHostsAllowedGroups, group) {
+			return nil
+		}
+	}
+
+	violations := []string{}
+	for _, host := range hosts {
+		if !host.tls && isWildcardHost(host.name) {
+			violations = append(violations, fmt.Sprintf("host %s", host))
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("wildcard hosts are not allowed: %s", strings.Join(violations, ", "))
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGlobMatches(t *testing.T) {
+	if !globMatches("*.apps.corp.example", "web.team-a.apps.corp.example") {
+		t.Errorf("Nested host should match the glob pattern")
+	}
+	if !globMatches("*.apps.corp.example", "API.apps.corp.example") {
+		t.Errorf("Host names should be compared case insensitively")
+	}
+	if globMatches("*.apps.corp.example", "apps.corp.example") {
+		t.Errorf("The suffix itself should not match the glob pattern")
+	}
+	if !globMatches("web-?.corp.example", "web-1.corp.example") {
+		t.Errorf("Host should match the single character wildcard")
+	}
+}
+
+func TestHostsNotAllowed(t *testing.T) {
+	response := validateFixture(t, "test_data/ingress-wildcard.json", `{"allowedHosts": ["*.bar.com"]}`)
+	expectRejectedWith(t, response, `host "*.foo.com" (spec.rules[1].host) is not allowed`)
+
+	response = validateFixture(t, "test_data/ingress-wildcard.json", `{"allowedHosts": ["*.bar.com", "*.foo.com"]}`)
+	expectAccepted(t, response)
+}
+
+func TestCatchAllRulesAreNotAllowed(t *testing.T) {
+	response := validateFixture(t, "test_data/team-a-catch-all.json", `{"allowedHosts": ["*.team-a.corp.example"]}`)
+	expectRejectedWith(t, response,
+		`spec.rules[1] matches all the hosts, not only the allowed ones; `+
+			`spec.defaultBackend matches all the hosts, not only the allowed ones`)
+
+	response = validateFixture(t, "test_data/default-backend-only.json", `{"allowedHosts": ["*.bar.com"]}`)
+	expectRejectedWith(t, response, `spec.defaultBackend matches all the hosts, not only the allowed ones`)
+
+	response = validateFixture(t, "test_data/team-a-catch-all.json", `{"deniedHosts": ["*.team-b.corp.example"]}`)
+	expectAccepted(t, response)
+}
+
+func TestHostsDenied(t *testing.T) {
+	response := validateFixture(t, "test_data/tls-with-extra-host.json", `{"deniedHosts": ["shared.*"]}`)
+	expectRejectedWith(t, response, `host "shared.bar.com" (spec.tls[0].hosts[1]) is denied`)
+
+	response = validateFixture(t, "test_data/tls-with-extra-host.json", `{"allowedHosts": ["*.bar.com"], "deniedHosts": ["foo.bar.com"]}`)
+	expectRejectedWith(t, response, `host "foo.bar.com" (spec.rules[0].host) is denied; host "foo.bar.com" (spec.tls[0].hosts[0]) is denied`)
+}
+
+func TestHostUnderDomain(t *testing.T) {
+	if !hostUnderDomain("corp.example", "corp.example") {
+		t.Errorf("Domain should be under itself")
+	}
+	if !hostUnderDomain("*.Team-A.corp.example", ".team-a.corp.example") {
+		t.Errorf("Wildcard host should be under the domain")
+	}
+	if hostUnderDomain("evilcorp.example", "corp.example") {
+		t.Errorf("Host sharing the suffix without the dot should not be under the domain")
+	}
+}
+
+func TestNamespaceDomains(t *testing.T) {
+	response := validateFixture(t, "test_data/team-a-hosts.json", `{"namespaceDomains": {"team-a": ["team-a.corp.example"], "team-b": ["team-b.corp.example"]}}`)
+	expectRejectedWith(t, response, `these hosts are outside of the domains owned by namespace "team-a" (team-a.corp.example): host "api.team-b.corp.example" (spec.rules[1].host)`)
+
+	response = validateFixture(t, "test_data/team-a-hosts.json", `{"namespaceDomains": {"team-*": ["team-b.corp.example"], "team-a": ["team-a.corp.example"]}}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/team-a-hosts.json", `{"namespaceDomains": {"team-b": ["team-b.corp.example"]}}`)
+	expectRejectedWith(t, response, `namespace "team-a" does not own any domain`)
+}
+
+func TestNamespaceDomainsRejectCatchAllRules(t *testing.T) {
+	response := validateFixture(t, "test_data/team-a-catch-all.json", `{"namespaceDomains": {"team-a": ["team-a.corp.example"]}}`)
+	expectRejectedWith(t, response,
+		`these fields match all the hosts, including the ones outside of the domains owned by namespace "team-a": spec.rules[1], spec.defaultBackend`)
+
+	response = validateFixture(t, "test_data/default-backend-only.json", `{"namespaceDomains": {"*": ["bar.com"]}}`)
+	expectRejectedWith(t, response, `these fields match all the hosts, including the ones outside of the domains owned by namespace`)
+}
+
+func namespaceWithAnnotations(name string, annotations map[string]string) corev1.Namespace {
+	return corev1.Namespace{
+		Metadata: &metav1.ObjectMeta{
+			Name:        name,
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestNamespaceAllowedHostsAnnotation(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Namespace", "", "team-a"): namespaceWithAnnotations("team-a", map[string]string{
+				"ingress.kubewarden.io/allowed-hosts": "*.team-a.corp.example, api.team-b.corp.example",
+			}),
+			resourceKey("Namespace", "", "team-b"): namespaceWithAnnotations("team-b", map[string]string{
+				"ingress.kubewarden.io/allowed-hosts": "*.team-b.corp.example",
+			}),
+		},
+	})
+
+	response := validateFixture(t, "test_data/team-a-hosts.json", `{"namespaceAllowedHostsAnnotation": "ingress.kubewarden.io/allowed-hosts"}`)
+	expectAccepted(t, response)
+}
+
+func TestNamespaceAllowedHostsAnnotationRejectsHosts(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Namespace", "", "team-a"): namespaceWithAnnotations("team-a", map[string]string{
+				"ingress.kubewarden.io/allowed-hosts": "*.team-a.corp.example",
+			}),
+		},
+	})
+
+	response := validateFixture(t, "test_data/team-a-hosts.json", `{"namespaceAllowedHostsAnnotation": "ingress.kubewarden.io/allowed-hosts"}`)
+	expectRejectedWith(t, response, `these hosts are not allowed by the ingress.kubewarden.io/allowed-hosts annotation of namespace "team-a": host "api.team-b.corp.example" (spec.rules[1].host)`)
+}
+
+func TestNamespaceAllowedHostsAnnotationRejectsCatchAllRules(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Namespace", "", "team-a"): namespaceWithAnnotations("team-a", map[string]string{
+				"ingress.kubewarden.io/allowed-hosts": "*.team-a.corp.example",
+			}),
+		},
+	})
+
+	response := validateFixture(t, "test_data/team-a-catch-all.json", `{"namespaceAllowedHostsAnnotation": "ingress.kubewarden.io/allowed-hosts"}`)
+	expectRejectedWith(t, response, `these fields match all the hosts, including the ones not allowed by the ingress.kubewarden.io/allowed-hosts annotation of namespace "team-a": spec.rules[1], spec.defaultBackend`)
+}
+
+func TestNamespaceWithoutAllowedHostsAnnotation(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Namespace", "", "team-a"): namespaceWithAnnotations("team-a", nil),
+		},
+	})
+
+	response := validateFixture(t, "test_data/team-a-hosts.json", `{"namespaceAllowedHostsAnnotation": "ingress.kubewarden.io/allowed-hosts"}`)
+	expectRejectedWith(t, response, `namespace "team-a" does not have the ingress.kubewarden.io/allowed-hosts annotation, no host can be used`)
+}
+
+func TestWildcardHostsCanBeDenied(t *testing.T) {
+	response := validateFixture(t, "test_data/ingress-wildcard.json", `{"denyWildcardHosts": true}`)
+	expectRejectedWith(t, response, `wildcard hosts are not allowed: host "*.foo.com" (spec.rules[1].host)`)
+
+	response = validateFixture(t, "test_data/wildcard-tls-nested-host.json", `{"denyWildcardHosts": true}`)
+	expectAccepted(t, response)
+}
+
+func TestWildcardHostsAllowedNamespacesAndGroups(t *testing.T) {
+	response := validateFixture(t, "test_data/ingress-wildcard-platform-admin.json", `{"denyWildcardHosts": true, "wildcardHostsAllowedNamespaces": ["team-*"]}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/ingress-wildcard-platform-admin.json", `{"denyWildcardHosts": true, "wildcardHostsAllowedGroups": ["platform-admins"]}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/ingress-wildcard-platform-admin.json", `{"denyWildcardHosts": true, "wildcardHostsAllowedNamespaces": ["team-b"], "wildcardHostsAllowedGroups": ["cluster-admins"]}`)
+	expectRejectedWith(t, response, "wildcard hosts are not allowed")
+}
+
+func TestHostIsRequired(t *testing.T) {
+	response := validateFixture(t, "test_data/hostless-rule.json", `{"requireHost": true}`)
+	expectRejectedWith(t, response, "rules without a host are not allowed: spec.rules[1]")
+
+	response = validateFixture(t, "test_data/hostless-rule.json", `{}`)
+	expectAccepted(t, response)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kubewarden/gjson"
+	"github.com/tidwall/match"
+)
+
+// Legacy annotation used to pick the ingress class before the
+// introduction of spec.ingressClassName
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+// ingressClassSources returns the ingress class set by
+// spec.ingressClassName and the one set by the legacy annotation
+func ingressClassSources(payload []byte) (field, annotation string) {
+	data := gjson.GetManyBytes(
+		payload,
+		"request.object.spec.ingressClassName",
+		"request.object.metadata.annotations."+strings.ReplaceAll(ingressClassAnnotation, ".", `\.`))
+	return data[0].String(), data[1].String()
+}
+
+// ingressClass returns the ingress class of the Ingress, read from
+// spec.ingressClassName or, when missing, from the legacy annotation.
+// An empty string means the Ingress relies on the default class.
+func ingressClass(payload []byte) string {
+	field, annotation := ingressClassSources(payload)
+	if field != "" {
+		return field
+	}
+	return annotation
+}
+
+// namespaceIngressClasses returns the patterns of the ingress classes
+// that can be used inside of the namespace, merging the entries of all
+// the NamespaceIngressClasses keys matching it. When no key matches,
+// the AllowedIngressClasses patterns are returned.
+func namespaceIngressClasses(namespace string, settings *Settings) []string {
+	classes := []string{}
+	matched := false
+	for pattern, patternClasses := range settings.NamespaceIngressClasses {
+		if match.Match(namespace, pattern) {
+			classes = append(classes, patternClasses...)
+			matched = true
+		}
+	}
+	if !matched {
+		return settings.AllowedIngressClasses
+	}
+	slices.Sort(classes)
+	return slices.Compact(classes)
+}
+
+// spec.ingressClassName and the legacy annotation must not be set to
+// different values. When RequireIngressClass is enabled, one of them
+// must be set. The class must match one of the patterns allowed inside
+// of the namespace of the Ingress, when there are any.
+func checkIngressClass(payload []byte, namespace string, settings *Settings) error {
+	field, annotation := ingressClassSources(payload)
+	if field != "" && annotation != "" && field != annotation {
+		return fmt.Errorf("spec.ingressClassName %q and the %s annotation %q must not differ", field, ingressClassAnnotation, annotation)
+	}
+
+	class := ingressClass(payload)
+	if class == "" {
+		if settings.RequireIngressClass {
+			return errors.New("the ingress class is required, it must be set with spec.ingressClassName")
+		}
+		return nil
+	}
+
+	allowed := namespaceIngressClasses(namespace, settings)
+	if len(allowed) == 0 || globMatchesAny(allowed, class) {
+		return nil
+	}
+	if len(settings.NamespaceIngressClasses) != 0 {
+		return fmt.Errorf("ingress class %q is not allowed inside of namespace %q, allowed classes: %s", class, namespace, strings.Join(allowed, ", "))
+	}
+	return fmt.Errorf("ingress class %q is not allowed, allowed classes: %s", class, strings.Join(allowed, ", "))
+}
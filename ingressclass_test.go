@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestIngressClass(t *testing.T) {
+	tests := []struct {
+		payload string
+		class   string
+	}{
+		{`{"request": {"object": {"spec": {"ingressClassName": "nginx"}}}}`, "nginx"},
+		{`{"request": {"object": {"metadata": {"annotations": {"kubernetes.io/ingress.class": "traefik"}}}}}`, "traefik"},
+		{`{"request": {"object": {"metadata": {"annotations": {"kubernetes.io/ingress.class": "traefik"}}, "spec": {"ingressClassName": "nginx"}}}}`, "nginx"},
+		{`{"request": {"object": {"spec": {}}}}`, ""},
+	}
+	for _, test := range tests {
+		if class := ingressClass([]byte(test.payload)); class != test.class {
+			t.Errorf("Payload %s: expected the ingress class %q, got %q", test.payload, test.class, class)
+		}
+	}
+}
+
+func TestIngressClassFieldAndAnnotationMustNotDiffer(t *testing.T) {
+	response := validateFixture(t, "test_data/ingress-class-mismatch.json", `{}`)
+	expectRejectedWith(t, response, `spec.ingressClassName "nginx-internal" and the kubernetes.io/ingress.class annotation "nginx-public" must not differ`)
+}
+
+func TestRequireIngressClass(t *testing.T) {
+	response := validateFixture(t, "test_data/team-a-hosts.json", `{"requireIngressClass": true}`)
+	expectRejectedWith(t, response, "the ingress class is required, it must be set with spec.ingressClassName")
+
+	response = validateFixture(t, "test_data/ingress-class-annotation.json", `{"requireIngressClass": true}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/team-a-hosts.json", `{"allowedIngressClasses": ["nginx-internal"]}`)
+	expectAccepted(t, response)
+}
+
+func TestAllowedIngressClasses(t *testing.T) {
+	response := validateFixture(t, "test_data/ingress-class-annotation.json", `{"allowedIngressClasses": ["nginx-internal", "traefik"]}`)
+	expectRejectedWith(t, response, `ingress class "nginx-public" is not allowed, allowed classes: nginx-internal, traefik`)
+
+	response = validateFixture(t, "test_data/ingress-class-annotation.json", `{"allowedIngressClasses": ["nginx-*"]}`)
+	expectAccepted(t, response)
+}
+
+func TestNamespaceIngressClasses(t *testing.T) {
+	settings := `{
+		"allowedIngressClasses": ["nginx-public", "nginx-internal"],
+		"namespaceIngressClasses": {"team-*": ["nginx-internal"], "team-a": ["traefik"]}
+	}`
+	response := validateFixture(t, "test_data/ingress-class-annotation.json", settings)
+	expectRejectedWith(t, response, `ingress class "nginx-public" is not allowed inside of namespace "team-a", allowed classes: nginx-internal, traefik`)
+
+	response = validateFixture(t, "test_data/nginx-injection-paths.json", `{
+		"allowedIngressClasses": ["nginx"],
+		"namespaceIngressClasses": {"team-*": ["nginx-internal"]}
+	}`)
+	expectAccepted(t, response)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/kubewarden/gjson"
+)
+
+// ingressCounts holds the size of the different sections of an Ingress
+type ingressCounts struct {
+	// Number of distinct hosts of spec.rules and spec.tls
+	hosts        int
+	rules        int
+	paths        int
+	pathsPerRule []int
+	tlsEntries   int
+}
+
+func countIngress(payload []byte) ingressCounts {
+	data := gjson.GetManyBytes(
+		payload,
+		"request.object.spec.rules",
+		"request.object.spec.tls")
+
+	counts := ingressCounts{
+		rules:        len(data[0].Array()),
+		pathsPerRule: make([]int, len(data[0].Array())),
+		tlsEntries:   len(data[1].Array()),
+	}
+	forEachPath(data[0], func(ruleIndex, _ int, _, _ gjson.Result) {
+		counts.paths++
+		counts.pathsPerRule[ruleIndex]++
+	})
+
+	hosts := mapset.NewThreadUnsafeSet[string]()
+	for _, host := range parseHosts(payload) {
+		hosts.Add(strings.ToLower(host.name))
+	}
+	counts.hosts = hosts.Cardinality()
+
+	return counts
+}
+
+// The size of the Ingress must not exceed the limits provided by the
+// settings. A limit set to zero is not enforced.
+func checkLimits(payload []byte, settings *Settings) error {
+	if settings.MaxHosts == 0 && settings.MaxRules == 0 && settings.MaxPathsPerRule == 0 &&
+		settings.MaxPaths == 0 && settings.MaxTlsEntries == 0 {
+		return nil
+	}
+
+	counts := countIngress(payload)
+	violations := []string{}
+	exceeds := func(what string, count, limit int) {
+		if limit != 0 && count > limit {
+			violations = append(violations, fmt.Sprintf("%s: %d, the maximum is %d", what, count, limit))
+		}
+	}
+
+	exceeds("too many hosts", counts.hosts, settings.MaxHosts)
+	exceeds("too many rules", counts.rules, settings.MaxRules)
+	for ruleIndex, paths := range counts.pathsPerRule {
+		exceeds(fmt.Sprintf("too many paths inside of spec.rules[%d]", ruleIndex), paths, settings.MaxPathsPerRule)
+	}
+	exceeds("too many paths", counts.paths, settings.MaxPaths)
+	exceeds("too many TLS entries", counts.tlsEntries, settings.MaxTlsEntries)
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	kubewarden_testing "github.com/kubewarden/policy-sdk-go/testing"
+)
+
+func TestCountIngress(t *testing.T) {
+	payload, err := kubewarden_testing.BuildValidationRequestFromFixture("test_data/many-paths.json", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("Unexpected error %+v", err)
+	}
+
+	counts := countIngress(payload)
+	if counts.hosts != 2 {
+		t.Errorf("Expected 2 distinct hosts, got %d", counts.hosts)
+	}
+	if counts.rules != 2 {
+		t.Errorf("Expected 2 rules, got %d", counts.rules)
+	}
+	if counts.paths != 4 {
+		t.Errorf("Expected 4 paths, got %d", counts.paths)
+	}
+	if len(counts.pathsPerRule) != 2 || counts.pathsPerRule[0] != 3 || counts.pathsPerRule[1] != 1 {
+		t.Errorf("Expected [3 1] paths per rule, got %v", counts.pathsPerRule)
+	}
+	if counts.tlsEntries != 2 {
+		t.Errorf("Expected 2 TLS entries, got %d", counts.tlsEntries)
+	}
+}
+
+func TestLimitsAreEnforced(t *testing.T) {
+	response := validateFixture(t, "test_data/many-paths.json", `{"maxHosts": 1, "maxRules": 1, "maxPathsPerRule": 2, "maxPaths": 3, "maxTLSEntries": 1}`)
+	expectRejectedWith(t, response,
+		"too many hosts: 2, the maximum is 1; "+
+			"too many rules: 2, the maximum is 1; "+
+			"too many paths inside of spec.rules[0]: 3, the maximum is 2; "+
+			"too many paths: 4, the maximum is 3; "+
+			"too many TLS entries: 2, the maximum is 1")
+
+	response = validateFixture(t, "test_data/many-paths.json", `{"maxHosts": 2, "maxRules": 2, "maxPathsPerRule": 3, "maxPaths": 4, "maxTLSEntries": 2}`)
+	expectAccepted(t, response)
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"encoding/json"
+
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+)
+
+var logWriter = kubewarden.KubewardenLogWriter{}
+
+// logWarning sends a warning message to the policy host. Warnings are
+// used to report findings that the user decided to tolerate.
+func logWarning(message string) {
+	line, err := json.Marshal(map[string]string{
+		"level":   "warn",
+		"message": message,
+	})
+	if err != nil {
+		return
+	}
+	_, _ = logWriter.Write(append(line, '\n'))
+}
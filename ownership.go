@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubewarden/gjson"
+	"github.com/tidwall/match"
+)
+
+// pathElements returns the elements of the path, ignoring the empty ones
+func pathElements(path string) []string {
+	elements := []string{}
+	for _, element := range strings.Split(path, "/") {
+		if element != "" {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// pathUnderPrefix reports whether all the requests matched by the path
+// are under the prefix. Prefixes are matched element by element, like
+// the Kubernetes Prefix paths: `/api` covers `/api` and `/api/v1`, but
+// not `/apiv1`.
+// ImplementationSpecific paths are assumed to be matched as plain string
+// prefixes, like ingress-nginx does: `/api` matches `/apiv1` too, hence
+// it is not under the `/api` prefix, while `/api/` is.
+func pathUnderPrefix(path, pathType, prefix string) bool {
+	pathElems := pathElements(path)
+	prefixElems := pathElements(prefix)
+	if len(prefixElems) > len(pathElems) {
+		return false
+	}
+	for index, element := range prefixElems {
+		if pathElems[index] != element {
+			return false
+		}
+	}
+	if pathType == pathTypeImplementationSpecific && len(prefixElems) != 0 &&
+		len(pathElems) == len(prefixElems) && !strings.HasSuffix(path, "/") {
+		return false
+	}
+	return true
+}
+
+// pathOwners returns the owned prefix of the host that is the longest
+// one covering the path, together with the namespace patterns owning it
+func pathOwners(path, pathType string, prefixes map[string][]string) (string, []string, bool) {
+	owner, namespaces, found := "", []string(nil), false
+	for prefix, prefixNamespaces := range prefixes {
+		if !pathUnderPrefix(path, pathType, prefix) {
+			continue
+		}
+		if !found || len(pathElements(prefix)) > len(pathElements(owner)) {
+			owner, namespaces, found = prefix, prefixNamespaces, true
+		}
+	}
+	return owner, namespaces, found
+}
+
+// pathOwnership returns the path prefixes owned on the host
+func (s *Settings) pathOwnership(host string) (map[string][]string, bool) {
+	for ownedHost, prefixes := range s.PathOwnership {
+		if strings.EqualFold(ownedHost, host) {
+			return prefixes, true
+		}
+	}
+	return nil, false
+}
+
+// When a host is split among namespaces by PathOwnership, each path of
+// the host must be under a prefix owned by the namespace of the Ingress.
+// When more owned prefixes cover the path, the longest one decides.
+func checkPathOwnership(payload []byte, namespace string, settings *Settings) error {
+	if len(settings.PathOwnership) == 0 {
+		return nil
+	}
+
+	violations := []string{}
+	rules := gjson.GetBytes(payload, "request.object.spec.rules")
+	forEachPath(rules, func(ruleIndex, pathIndex int, rule, path gjson.Result) {
+		routed := routedPath{
+			host:     rule.Get("host").String(),
+			path:     path.Get("path").String(),
+			pathType: path.Get("pathType").String(),
+		}
+		prefixes, owned := settings.pathOwnership(routed.host)
+		if !owned {
+			return
+		}
+
+		field := pathField(ruleIndex, pathIndex)
+		prefix, namespaces, found := pathOwners(routed.path, routed.pathType, prefixes)
+		if !found {
+			violations = append(violations, fmt.Sprintf("%s: %s is outside of the path prefixes owned by the namespaces", field, routed))
+			return
+		}
+		for _, pattern := range namespaces {
+			if match.Match(namespace, pattern) {
+				return
+			}
+		}
+		violations = append(violations,
+			fmt.Sprintf("%s: %s is under the prefix %q, which is not owned by namespace %q", field, routed, prefix, namespace))
+	})
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPathUnderPrefix(t *testing.T) {
+	tests := []struct {
+		path, pathType, prefix string
+		under                  bool
+	}{
+		{"/api", pathTypePrefix, "/api", true},
+		{"/api/v1", pathTypePrefix, "/api/", true},
+		{"/api/v1", pathTypeExact, "/api", true},
+		{"/apiv1", pathTypePrefix, "/api", false},
+		{"/", pathTypePrefix, "/api", false},
+		{"/anything", pathTypeExact, "/", true},
+		{"/api", pathTypeImplementationSpecific, "/api", false},
+		{"/api/", pathTypeImplementationSpecific, "/api", true},
+		{"/api/v", pathTypeImplementationSpecific, "/api", true},
+		{"/api", pathTypeImplementationSpecific, "/", true},
+	}
+	for _, test := range tests {
+		if under := pathUnderPrefix(test.path, test.pathType, test.prefix); under != test.under {
+			t.Errorf("%s path %q under %q: expected %v, got %v", test.pathType, test.path, test.prefix, test.under, under)
+		}
+	}
+}
+
+func TestPathOwners(t *testing.T) {
+	prefixes := map[string][]string{
+		"/":        {"platform"},
+		"/team-a":  {"team-a"},
+		"/billing": {"billing"},
+	}
+
+	prefix, namespaces, found := pathOwners("/team-a/v1", pathTypePrefix, prefixes)
+	if !found || prefix != "/team-a" || len(namespaces) != 1 || namespaces[0] != "team-a" {
+		t.Errorf("Expected the /team-a prefix to own the path, got %q %v", prefix, namespaces)
+	}
+
+	prefix, _, found = pathOwners("/status", pathTypeExact, prefixes)
+	if !found || prefix != "/" {
+		t.Errorf("Expected the / prefix to own the path, got %q", prefix)
+	}
+
+	if _, _, found = pathOwners("/status", pathTypeExact, map[string][]string{"/billing": {"billing"}}); found {
+		t.Errorf("No prefix should own the path")
+	}
+}
+
+func TestPathOwnership(t *testing.T) {
+	response := validateFixture(t, "test_data/shared-host-paths.json", `{"pathOwnership": {"api.corp.example": {
+		"/": ["platform"],
+		"/team-a": ["team-*"],
+		"/billing": ["billing"]
+	}}}`)
+	expectRejectedWith(t, response,
+		`spec.rules[0].http.paths[2]: Exact path "/billing/invoices" of host "api.corp.example" is under the prefix "/billing", which is not owned by namespace "team-a"; `+
+			`spec.rules[0].http.paths[3]: Prefix path "/" of host "api.corp.example" is under the prefix "/", which is not owned by namespace "team-a"`)
+
+	response = validateFixture(t, "test_data/shared-host-paths.json", `{"pathOwnership": {"API.corp.example": {
+		"/team-a": ["team-a"]
+	}}}`)
+	expectRejectedWith(t, response,
+		`spec.rules[0].http.paths[2]: Exact path "/billing/invoices" of host "api.corp.example" is outside of the path prefixes owned by the namespaces; `+
+			`spec.rules[0].http.paths[3]: Prefix path "/" of host "api.corp.example" is outside of the path prefixes owned by the namespaces`)
+
+	response = validateFixture(t, "test_data/shared-host-paths.json", `{"pathOwnership": {"api.corp.example": {
+		"/": ["team-a", "platform"],
+		"/billing": ["billing", "team-a"]
+	}}}`)
+	expectAccepted(t, response)
+}
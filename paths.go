@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kubewarden/gjson"
+)
+
+// Path types defined by the networking.k8s.io/v1 API
+const (
+	pathTypeExact                  = "Exact"
+	pathTypePrefix                 = "Prefix"
+	pathTypeImplementationSpecific = "ImplementationSpecific"
+)
+
+var pathTypes = []string{pathTypeExact, pathTypePrefix, pathTypeImplementationSpecific}
+
+// Characters with a special meaning inside of regular expressions. The
+// dot is left out, because it is common inside of plain paths.
+const regexMetacharacters = `^$*+?()[]{}|\`
+
+// forEachPath calls fn for each path defined inside of the given
+// spec.rules list, together with the indexes of the rule and of the
+// path
+func forEachPath(rules gjson.Result, fn func(ruleIndex, pathIndex int, rule, path gjson.Result)) {
+	for ruleIndex, rule := range rules.Array() {
+		for pathIndex, path := range rule.Get("http.paths").Array() {
+			fn(ruleIndex, pathIndex, rule, path)
+		}
+	}
+}
+
+// pathField returns the field path of the given path of a rule
+func pathField(ruleIndex, pathIndex int) string {
+	return fmt.Sprintf("spec.rules[%d].http.paths[%d]", ruleIndex, pathIndex)
+}
+
+// validatePath checks the path is absolute, does not traverse the parent
+// directory, and does not contain empty segments nor control characters.
+// Prefix and Exact paths are matched literally, hence they cannot
+// contain regular expression metacharacters.
+func validatePath(path, pathType string) error {
+	if path == "" && pathType == pathTypeImplementationSpecific {
+		return nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return errors.New("it must be absolute")
+	}
+	for _, char := range path {
+		if char < 0x20 || char == 0x7f {
+			return fmt.Errorf("it contains the control character %q", char)
+		}
+	}
+	if strings.Contains(path, "//") {
+		return errors.New(`it contains "//"`)
+	}
+	if slices.Contains(strings.Split(path, "/"), "..") {
+		return errors.New(`it contains ".."`)
+	}
+	if pathType == pathTypePrefix || pathType == pathTypeExact {
+		if index := strings.IndexAny(path, regexMetacharacters); index >= 0 {
+			return fmt.Errorf("it contains the regular expression metacharacter %q, which is not allowed for %s paths", path[index], pathType)
+		}
+	}
+	return nil
+}
+
+// When AllowedPathTypes is not empty, the type of each path must be one
+// of them. When ValidatePaths is enabled, each path must pass the
+// validatePath checks.
+func checkPaths(payload []byte, settings *Settings) error {
+	if len(settings.AllowedPathTypes) == 0 && !settings.ValidatePaths {
+		return nil
+	}
+
+	violations := []string{}
+	rules := gjson.GetBytes(payload, "request.object.spec.rules")
+	forEachPath(rules, func(ruleIndex, pathIndex int, _, path gjson.Result) {
+		field := pathField(ruleIndex, pathIndex)
+		pathType := path.Get("pathType").String()
+		if len(settings.AllowedPathTypes) != 0 && !slices.Contains(settings.AllowedPathTypes, pathType) {
+			violations = append(violations, fmt.Sprintf("%s.pathType: path type %q is not allowed", field, pathType))
+		}
+		if !settings.ValidatePaths {
+			return
+		}
+		value := path.Get("path").String()
+		if err := validatePath(value, pathType); err != nil {
+			violations = append(violations, fmt.Sprintf("%s.path: path %q is invalid, %s", field, value, err))
+		}
+	})
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
+
+// strictPathPattern is the character class of the paths accepted by the
+// strict path validation: the unreserved characters of RFC 3986 and the
+// slash. Everything else, like `;`, `{`, `"` or whitespaces, can be used
+// to inject configuration into some ingress controllers, see
+// CVE-2021-25745.
+var strictPathPattern = regexp.MustCompile(`^/[A-Za-z0-9._~/-]*$`)
+
+// validateStrictPath checks the path uses only the characters of the
+// strict path pattern, on top of the validatePath checks
+func validateStrictPath(path string) error {
+	if !strictPathPattern.MatchString(path) {
+		if !strings.HasPrefix(path, "/") {
+			return errors.New("it must be absolute")
+		}
+		return errors.New("only the characters A-Z a-z 0-9 . _ ~ - / can be used")
+	}
+	return validatePath(path, "")
+}
+
+// When the ingress class of the Ingress matches one of the
+// StrictPathValidationIngressClasses patterns, every path must pass the
+// validateStrictPath checks, whatever its type. The Ingresses without a
+// class are handled by the default class of the cluster: they are
+// checked as if they used the DefaultIngressClass or, when it is not
+// known, they are always checked.
+func checkStrictPaths(payload []byte, settings *Settings) error {
+	if len(settings.StrictPathValidationIngressClasses) == 0 {
+		return nil
+	}
+	class := ingressClass(payload)
+	if class == "" {
+		class = settings.DefaultIngressClass
+	}
+	if class != "" && !globMatchesAny(settings.StrictPathValidationIngressClasses, class) {
+		return nil
+	}
+
+	violations := []string{}
+	rules := gjson.GetBytes(payload, "request.object.spec.rules")
+	forEachPath(rules, func(ruleIndex, pathIndex int, _, path gjson.Result) {
+		value := path.Get("path").String()
+		if err := validateStrictPath(value); err != nil {
+			violations = append(violations, fmt.Sprintf("%s.path: path %q is not allowed for the ingress class %q, %s", pathField(ruleIndex, pathIndex), value, class, err))
+		}
+	})
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
+
+// routedPath identifies the requests routed by a path of an Ingress rule
+type routedPath struct {
+	host     string
+	path     string
+	pathType string
+}
+
+func (p routedPath) String() string {
+	host := fmt.Sprintf("host %q", p.host)
+	if p.host == "" {
+		host = "all the hosts"
+	}
+	return fmt.Sprintf("%s path %q of %s", p.pathType, p.path, host)
+}
+
+// normalizePrefix returns the path without the trailing slashes. Prefix
+// paths are matched element by element, hence `/foo` and `/foo/` match
+// the same requests.
+func normalizePrefix(path string) string {
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+	return "/"
+}
+
+// Paths with the same host, path and type are duplicates. Prefix paths
+// of the same host that differ only by their trailing slashes match the
+// same requests, hence the later ones are shadowed. Requests are routed
+// to the longest matching path, Exact paths first, hence a broader path
+// does not shadow a more specific one.
+func checkDuplicatePaths(payload []byte, settings *Settings) error {
+	if settings.DuplicatePathsAction == ActionAccept && settings.ShadowedPathsAction == ActionAccept {
+		return nil
+	}
+
+	duplicates := []string{}
+	shadowed := []string{}
+	seen := map[routedPath]string{}
+	prefixes := map[routedPath]routedPath{}
+	prefixFields := map[routedPath]string{}
+
+	rules := gjson.GetBytes(payload, "request.object.spec.rules")
+	forEachPath(rules, func(ruleIndex, pathIndex int, rule, path gjson.Result) {
+		field := pathField(ruleIndex, pathIndex)
+		routed := routedPath{
+			host:     strings.ToLower(rule.Get("host").String()),
+			path:     path.Get("path").String(),
+			pathType: path.Get("pathType").String(),
+		}
+		if other, found := seen[routed]; found {
+			duplicates = append(duplicates, fmt.Sprintf("%s: %s duplicates %s", field, routed, other))
+			return
+		}
+		seen[routed] = field
+
+		if routed.pathType != pathTypePrefix {
+			return
+		}
+		normalized := routed
+		normalized.path = normalizePrefix(routed.path)
+		if other, found := prefixes[normalized]; found {
+			shadowed = append(shadowed, fmt.Sprintf("%s: %s is shadowed by the equivalent path %q (%s)", field, routed, other.path, prefixFields[normalized]))
+			return
+		}
+		prefixes[normalized] = routed
+		prefixFields[normalized] = field
+	})
+
+	if len(duplicates) != 0 {
+		msg := fmt.Sprintf("duplicate paths found: %s", strings.Join(duplicates, "; "))
+		if err := settings.DuplicatePathsAction.report(msg); err != nil {
+			return err
+		}
+	}
+	if len(shadowed) != 0 {
+		msg := fmt.Sprintf("shadowed paths found: %s", strings.Join(shadowed, "; "))
+		return settings.ShadowedPathsAction.report(msg)
+	}
+	return nil
+}
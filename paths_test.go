@@ -0,0 +1,156 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestValidatePath(t *testing.T) {
+	valid := []struct {
+		path, pathType string
+	}{
+		{"/", pathTypePrefix},
+		{"/static/app.v1.js", pathTypeExact},
+		{"/foo..bar/", pathTypePrefix},
+		{"/api/(v1|v2)", pathTypeImplementationSpecific},
+		{"", pathTypeImplementationSpecific},
+	}
+	for _, test := range valid {
+		if err := validatePath(test.path, test.pathType); err != nil {
+			t.Errorf("%s path %q should be valid: %+v", test.pathType, test.path, err)
+		}
+	}
+
+	invalid := []struct {
+		path, pathType, message string
+	}{
+		{"", pathTypePrefix, "it must be absolute"},
+		{"api", pathTypeImplementationSpecific, "it must be absolute"},
+		{"/static/../admin", pathTypeImplementationSpecific, `it contains ".."`},
+		{"/..", pathTypeExact, `it contains ".."`},
+		{"/files//", pathTypePrefix, `it contains "//"`},
+		{"/foo\nbar", pathTypePrefix, `it contains the control character '\n'`},
+		{"/v[0-9]+", pathTypePrefix, "it contains the regular expression metacharacter '[', which is not allowed for Prefix paths"},
+		{"/foo$", pathTypeExact, "it contains the regular expression metacharacter '$', which is not allowed for Exact paths"},
+	}
+	for _, test := range invalid {
+		err := validatePath(test.path, test.pathType)
+		if err == nil {
+			t.Errorf("%s path %q should be invalid", test.pathType, test.path)
+			continue
+		}
+		if err.Error() != test.message {
+			t.Errorf("%s path %q: expected error %q, got %q", test.pathType, test.path, test.message, err)
+		}
+	}
+}
+
+func TestPathsAreValidated(t *testing.T) {
+	response := validateFixture(t, "test_data/invalid-paths.json", `{}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/invalid-paths.json", `{"validatePaths": true}`)
+	expectRejectedWith(t, response,
+		`spec.rules[0].http.paths[1].path: path "/static/../admin" is invalid, it contains ".."; `+
+			`spec.rules[1].http.paths[0].path: path "admin" is invalid, it must be absolute; `+
+			`spec.rules[1].http.paths[1].path: path "/files//" is invalid, it contains "//"; `+
+			`spec.rules[1].http.paths[2].path: path "/v[0-9]+" is invalid, it contains the regular expression metacharacter '[', which is not allowed for Prefix paths`)
+}
+
+func TestAllowedPathTypes(t *testing.T) {
+	response := validateFixture(t, "test_data/invalid-paths.json", `{"allowedPathTypes": ["Prefix", "Exact"]}`)
+	expectRejectedWith(t, response, `spec.rules[0].http.paths[2].pathType: path type "ImplementationSpecific" is not allowed`)
+
+	response = validateFixture(t, "test_data/invalid-paths.json", `{"allowedPathTypes": ["Prefix", "Exact", "ImplementationSpecific"]}`)
+	expectAccepted(t, response)
+}
+
+func TestValidateStrictPath(t *testing.T) {
+	for _, path := range []string{"/", "/static/app-v1.2.js", "/users/~alice/files_1"} {
+		if err := validateStrictPath(path); err != nil {
+			t.Errorf("Path %q should be valid: %+v", path, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"api":                    "it must be absolute",
+		"/api;rewrite ^ /admin;": "only the characters A-Z a-z 0-9 . _ ~ - / can be used",
+		"/v1/{id}":               "only the characters A-Z a-z 0-9 . _ ~ - / can be used",
+		"/a%20b":                 "only the characters A-Z a-z 0-9 . _ ~ - / can be used",
+		"/static/../admin":       `it contains ".."`,
+		"/files//":               `it contains "//"`,
+	}
+	for path, message := range invalid {
+		err := validateStrictPath(path)
+		if err == nil {
+			t.Errorf("Path %q should be invalid", path)
+			continue
+		}
+		if err.Error() != message {
+			t.Errorf("Path %q: expected error %q, got %q", path, message, err)
+		}
+	}
+}
+
+func TestStrictPathValidationIngressClasses(t *testing.T) {
+	response := validateFixture(t, "test_data/nginx-injection-paths.json", `{"strictPathValidationIngressClasses": ["traefik"]}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/nginx-injection-paths.json", `{"strictPathValidationIngressClasses": ["nginx*"]}`)
+	expectRejectedWith(t, response,
+		`spec.rules[0].http.paths[1].path: path "/api;proxy_pass http://evil.example;" is not allowed for the ingress class "nginx", only the characters A-Z a-z 0-9 . _ ~ - / can be used`)
+
+	response = validateFixture(t, "test_data/invalid-paths.json", `{"strictPathValidationIngressClasses": ["*"]}`)
+	expectRejectedWith(t, response,
+		`spec.rules[0].http.paths[2].path: path "/api/(v1|v2)" is not allowed for the ingress class "", only the characters A-Z a-z 0-9 . _ ~ - / can be used`)
+}
+
+func TestStrictPathValidationWithoutIngressClass(t *testing.T) {
+	response := validateFixture(t, "test_data/nginx-injection-paths-without-class.json", `{"strictPathValidationIngressClasses": ["nginx"]}`)
+	expectRejectedWith(t, response,
+		`spec.rules[0].http.paths[1].path: path "/api;proxy_pass http://evil.example;" is not allowed for the ingress class "", only the characters A-Z a-z 0-9 . _ ~ - / can be used`)
+
+	response = validateFixture(t, "test_data/nginx-injection-paths-without-class.json", `{"strictPathValidationIngressClasses": ["nginx"], "defaultIngressClass": "nginx"}`)
+	expectRejectedWith(t, response,
+		`spec.rules[0].http.paths[1].path: path "/api;proxy_pass http://evil.example;" is not allowed for the ingress class "nginx", only the characters A-Z a-z 0-9 . _ ~ - / can be used`)
+
+	response = validateFixture(t, "test_data/nginx-injection-paths-without-class.json", `{"strictPathValidationIngressClasses": ["nginx"], "defaultIngressClass": "traefik"}`)
+	expectAccepted(t, response)
+}
+
+func TestNormalizePrefix(t *testing.T) {
+	paths := map[string]string{
+		"/":      "/",
+		"//":     "/",
+		"/foo":   "/foo",
+		"/foo/":  "/foo",
+		"/foo//": "/foo",
+	}
+	for path, expected := range paths {
+		if normalized := normalizePrefix(path); normalized != expected {
+			t.Errorf("Path %q: expected %q, got %q", path, expected, normalized)
+		}
+	}
+}
+
+func TestDuplicatePaths(t *testing.T) {
+	response := validateFixture(t, "test_data/duplicate-paths.json", `{}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/duplicate-paths.json", `{"duplicatePathsAction": "reject"}`)
+	expectRejectedWith(t, response,
+		`duplicate paths found: spec.rules[1].http.paths[1]: Prefix path "/" of host "foo.bar.com" duplicates spec.rules[0].http.paths[0]`)
+}
+
+func TestShadowedPaths(t *testing.T) {
+	// The Prefix and Exact "/api" paths next to the Prefix "/" path are
+	// reachable, only the equivalent "/api/" path is reported
+	response := validateFixture(t, "test_data/duplicate-paths.json", `{"duplicatePathsAction": "accept", "shadowedPathsAction": "reject"}`)
+	expected := `shadowed paths found: spec.rules[1].http.paths[0]: Prefix path "/api/" of host "foo.bar.com" is shadowed by the equivalent path "/api" (spec.rules[0].http.paths[1])`
+	expectRejectedWith(t, response, expected)
+	if response.Message != nil && *response.Message != expected {
+		t.Errorf("Expected rejection message %q, got %q", expected, *response.Message)
+	}
+
+	response = validateFixture(t, "test_data/many-paths.json", `{"duplicatePathsAction": "reject", "shadowedPathsAction": "reject"}`)
+	expectAccepted(t, response)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const maxPort = 65535
+
+// PortRange is an inclusive range of ports. A single port is
+// represented by a range where First and Last are the same.
+type PortRange struct {
+	First uint64
+	Last  uint64
+}
+
+func parsePortRange(value string) (PortRange, error) {
+	first, last, isRange := strings.Cut(strings.TrimSpace(value), "-")
+	if !isRange {
+		last = first
+	}
+
+	firstPort, err := strconv.ParseUint(strings.TrimSpace(first), 10, 64)
+	if err != nil {
+		return PortRange{}, fmt.Errorf("invalid port range %q", value)
+	}
+	lastPort, err := strconv.ParseUint(strings.TrimSpace(last), 10, 64)
+	if err != nil {
+		return PortRange{}, fmt.Errorf("invalid port range %q", value)
+	}
+
+	portRange := PortRange{First: firstPort, Last: lastPort}
+	if err := portRange.validate(); err != nil {
+		return PortRange{}, err
+	}
+	return portRange, nil
+}
+
+func (r PortRange) validate() error {
+	if r.Last > maxPort {
+		return fmt.Errorf("port range %s is out of bounds, ports cannot be greater than %d", r, maxPort)
+	}
+	if r.First > r.Last {
+		return fmt.Errorf("invalid port range %s, the first port is greater than the last one", r)
+	}
+	return nil
+}
+
+func (r PortRange) Contains(port uint64) bool {
+	return r.First <= port && port <= r.Last
+}
+
+func (r PortRange) Overlaps(other PortRange) bool {
+	return r.First <= other.Last && other.First <= r.Last
+}
+
+func (r PortRange) String() string {
+	if r.First == r.Last {
+		return strconv.FormatUint(r.First, 10)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// PortSet is a list of port ranges. It's unmarshalled from a JSON list
+// made of port numbers and "first-last" range strings, for example:
+// `[443, "8000-8999"]`
+type PortSet []PortRange
+
+// Match returns the first range of the set containing the given port
+func (s PortSet) Match(port uint64) (PortRange, bool) {
+	for _, portRange := range s {
+		if portRange.Contains(port) {
+			return portRange, true
+		}
+	}
+	return PortRange{}, false
+}
+
+func (s PortSet) Contains(port uint64) bool {
+	_, found := s.Match(port)
+	return found
+}
+
+func (s *PortSet) UnmarshalJSON(data []byte) error {
+	rawEntries := []json.RawMessage{}
+	if err := json.Unmarshal(data, &rawEntries); err != nil {
+		return err
+	}
+
+	set := make(PortSet, 0, len(rawEntries))
+	for _, rawEntry := range rawEntries {
+		var port uint64
+		if err := json.Unmarshal(rawEntry, &port); err == nil {
+			portRange := PortRange{First: port, Last: port}
+			if err := portRange.validate(); err != nil {
+				return err
+			}
+			set = append(set, portRange)
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(rawEntry, &value); err != nil {
+			return fmt.Errorf("invalid port entry %s, must be a number or a range string", rawEntry)
+		}
+		portRange, err := parsePortRange(value)
+		if err != nil {
+			return err
+		}
+		set = append(set, portRange)
+	}
+
+	*s = set
+	return nil
+}
+
+func (s PortSet) MarshalJSON() ([]byte, error) {
+	entries := make([]interface{}, 0, len(s))
+	for _, portRange := range s {
+		if portRange.First == portRange.Last {
+			entries = append(entries, portRange.First)
+		} else {
+			entries = append(entries, portRange.String())
+		}
+	}
+	return json.Marshal(entries)
+}
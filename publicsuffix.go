@@ -0,0 +1,155 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Snapshot of the Public Suffix List, refreshed by running
+// `make update-public-suffix-list`
+//
+//go:embed public_suffix_list.dat
+var publicSuffixListData string
+
+type publicSuffixRule int
+
+const (
+	publicSuffixRuleNormal publicSuffixRule = 1 << iota
+	publicSuffixRuleWildcard
+	publicSuffixRuleException
+)
+
+var (
+	publicSuffixRules     map[string]publicSuffixRule
+	publicSuffixRulesOnce sync.Once
+)
+
+// parsePublicSuffixList returns the rules of the list, indexed by the
+// domain they apply to. Wildcard rules are indexed by their parent
+// domain, exception rules by the domain without the leading `!`.
+func parsePublicSuffixList(data string) map[string]publicSuffixRule {
+	rules := map[string]publicSuffixRule{}
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+		rule := strings.ToLower(fields[0])
+		if parent, found := strings.CutPrefix(rule, "*."); found {
+			rules[parent] |= publicSuffixRuleWildcard
+		} else if domain, found := strings.CutPrefix(rule, "!"); found {
+			rules[domain] |= publicSuffixRuleException
+		} else {
+			rules[rule] |= publicSuffixRuleNormal
+		}
+	}
+	return rules
+}
+
+func loadPublicSuffixRules() map[string]publicSuffixRule {
+	publicSuffixRulesOnce.Do(func() {
+		publicSuffixRules = parsePublicSuffixList(publicSuffixListData)
+	})
+	return publicSuffixRules
+}
+
+// publicSuffixLabels returns the number of trailing labels of the host
+// that make up its public suffix, following the algorithm described at
+// https://publicsuffix.org/list/. When no rule of the list matches, the
+// implicit `*` rule makes the top level domain the public suffix and
+// listed is false.
+func publicSuffixLabels(host string) (count int, listed bool) {
+	name := strings.ToLower(host)
+	if decoded, err := decodeHostname(name); err == nil {
+		name = decoded
+	}
+	labels := strings.Split(name, ".")
+	rules := loadPublicSuffixRules()
+
+	for index := range labels {
+		candidate := strings.Join(labels[index:], ".")
+		rule := rules[candidate]
+		if rule&publicSuffixRuleException != 0 {
+			return len(labels) - index - 1, true
+		}
+		if rule&publicSuffixRuleNormal != 0 {
+			return len(labels) - index, true
+		}
+		if index+1 < len(labels) && rules[strings.Join(labels[index+1:], ".")]&publicSuffixRuleWildcard != 0 {
+			return len(labels) - index, true
+		}
+	}
+	return 1, false
+}
+
+// isPublicSuffix reports whether the host is a public suffix, like
+// `com`, `co.uk` or `github.io`. The top level domains that are not
+// listed, like `ck`, which is only covered by the `*.ck` wildcard rule,
+// or `corp`, are public suffixes only when implicitRule is true.
+func isPublicSuffix(host string, implicitRule bool) bool {
+	count, listed := publicSuffixLabels(host)
+	return (listed || implicitRule) && count == len(strings.Split(host, "."))
+}
+
+// registrableDomain returns the public suffix of the host plus one
+// label, like `example.co.uk` for `api.example.co.uk`. Public suffixes
+// do not have a registrable domain.
+func registrableDomain(host string) (string, bool) {
+	labels := strings.Split(strings.ToLower(strings.TrimPrefix(host, "*.")), ".")
+	count, _ := publicSuffixLabels(strings.Join(labels, "."))
+	if count >= len(labels) {
+		return "", false
+	}
+	return strings.Join(labels[len(labels)-count-1:], "."), true
+}
+
+// Hosts that are public suffixes, and wildcard hosts covering all the
+// domains under a public suffix, are rejected unless the check is
+// disabled. The unlisted top level domains are handled as public
+// suffixes only when RejectUnlistedTopLevelDomains is enabled. When
+// AllowedRegistrableDomains is not empty, the registrable domain of each
+// host must be one of them.
+func checkPublicSuffixes(hosts []ingressHost, settings *Settings) error {
+	violations := []string{}
+	for _, host := range hosts {
+		if !settings.DisablePublicSuffixCheck {
+			if isPublicSuffix(host.name, settings.RejectUnlistedTopLevelDomains) {
+				violations = append(violations, fmt.Sprintf("host %s is a public suffix", host))
+				continue
+			}
+			if suffix, isWildcard := strings.CutPrefix(host.name, "*."); isWildcard && isPublicSuffix(suffix, settings.RejectUnlistedTopLevelDomains) {
+				violations = append(violations, fmt.Sprintf("host %s covers all the domains under the public suffix %q", host, suffix))
+				continue
+			}
+		}
+
+		if len(settings.AllowedRegistrableDomains) == 0 {
+			continue
+		}
+		domain, found := registrableDomain(host.name)
+		if !found {
+			violations = append(violations, fmt.Sprintf("host %s does not have a registrable domain", host))
+			continue
+		}
+		if !settings.allowedRegistrableDomain(domain) {
+			violations = append(violations, fmt.Sprintf("host %s is under the registrable domain %q, which is not allowed", host, domain))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
+
+func (s *Settings) allowedRegistrableDomain(domain string) bool {
+	for _, allowed := range s.AllowedRegistrableDomains {
+		if strings.EqualFold(allowed, domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPublicSuffixes(t *testing.T) {
+	suffixes := []string{"com", "co.uk", "CO.UK", "github.io", "foo.ck", "xn--55qx5d.cn", "公司.cn"}
+	for _, host := range suffixes {
+		if !isPublicSuffix(host, false) || !isPublicSuffix(host, true) {
+			t.Errorf("%s should be a public suffix", host)
+		}
+	}
+
+	unlisted := []string{"ck", "localhost", "unlisted-tld"}
+	for _, host := range unlisted {
+		if isPublicSuffix(host, false) {
+			t.Errorf("%s should not be a public suffix without the implicit rule", host)
+		}
+		if !isPublicSuffix(host, true) {
+			t.Errorf("%s should be a public suffix with the implicit rule", host)
+		}
+	}
+
+	notSuffixes := []string{"example.com", "example.co.uk", "www.ck", "foo.internal", "api.unlisted-tld"}
+	for _, host := range notSuffixes {
+		if isPublicSuffix(host, false) || isPublicSuffix(host, true) {
+			t.Errorf("%s should not be a public suffix", host)
+		}
+	}
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	domains := map[string]string{
+		"api.example.co.uk":         "example.co.uk",
+		"*.example.com":             "example.com",
+		"v1.api.Example.com":        "example.com",
+		"www.ck":                    "www.ck",
+		"a.b.foo.ck":                "b.foo.ck",
+		"web.xn--55qx5d.cn":         "web.xn--55qx5d.cn",
+		"api.corp.internal":         "corp.internal",
+		"pages.user.github.io":      "user.github.io",
+		"user.github.io":            "user.github.io",
+		"example.kawasaki.jp":       "",
+		"web.city.kawasaki.jp":      "city.kawasaki.jp",
+		"city.kawasaki.jp":          "city.kawasaki.jp",
+		"com":                       "",
+		"*.com":                     "",
+		"foo.bar.kawasaki.jp":       "foo.bar.kawasaki.jp",
+		"example.co.uk":             "example.co.uk",
+		"www.example.pvt.k12.ma.us": "example.pvt.k12.ma.us",
+	}
+	for host, expected := range domains {
+		domain, found := registrableDomain(host)
+		if found != (expected != "") || domain != expected {
+			t.Errorf("Registrable domain of %s: expected %q, got %q", host, expected, domain)
+		}
+	}
+}
+
+func TestPublicSuffixHostsAreRejected(t *testing.T) {
+	response := validateFixture(t, "test_data/public-suffix-hosts.json", `{}`)
+	expectRejectedWith(t, response,
+		`host "*.co.uk" (spec.rules[1].host) covers all the domains under the public suffix "co.uk"; `+
+			`host "github.io" (spec.rules[2].host) is a public suffix`)
+
+	response = validateFixture(t, "test_data/public-suffix-hosts.json", `{"disablePublicSuffixCheck": true}`)
+	expectAccepted(t, response)
+}
+
+func TestUnlistedTopLevelDomains(t *testing.T) {
+	response := validateFixture(t, "test_data/unlisted-tld-hosts.json", `{}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/unlisted-tld-hosts.json", `{"rejectUnlistedTopLevelDomains": true}`)
+	expectRejectedWith(t, response,
+		`host "myapp" (spec.rules[0].host) is a public suffix; `+
+			`host "*.corp" (spec.rules[1].host) covers all the domains under the public suffix "corp"`)
+
+	response = validateFixture(t, "test_data/unlisted-tld-hosts.json", `{"rejectUnlistedTopLevelDomains": true, "disablePublicSuffixCheck": true}`)
+	expectAccepted(t, response)
+}
+
+func TestAllowedRegistrableDomains(t *testing.T) {
+	response := validateFixture(t, "test_data/ingress-wildcard.json", `{"allowedRegistrableDomains": ["bar.com"]}`)
+	expectRejectedWith(t, response, `host "*.foo.com" (spec.rules[1].host) is under the registrable domain "foo.com", which is not allowed`)
+
+	response = validateFixture(t, "test_data/ingress-wildcard.json", `{"allowedRegistrableDomains": ["bar.com", "foo.com"]}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/public-suffix-hosts.json", `{"disablePublicSuffixCheck": true, "allowedRegistrableDomains": ["example.co.uk"]}`)
+	expectRejectedWith(t, response,
+		`host "*.co.uk" (spec.rules[1].host) does not have a registrable domain; `+
+			`host "github.io" (spec.rules[2].host) does not have a registrable domain`)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parameters of the Punycode encoding, as defined by RFC 3492
+const (
+	punycodeBase        int32 = 36
+	punycodeTMin        int32 = 1
+	punycodeTMax        int32 = 26
+	punycodeSkew        int32 = 38
+	punycodeDamp        int32 = 700
+	punycodeInitialBias int32 = 72
+	punycodeInitialN    int32 = 128
+
+	// Labels are at most 63 characters long, hence decoded labels
+	// cannot be longer than this
+	punycodeMaxDecodedLength = 63
+)
+
+// decodeLabel returns the Unicode form of a DNS label. Labels that are
+// not punycode encoded are returned as they are.
+func decodeLabel(label string) (string, error) {
+	encoded, found := strings.CutPrefix(strings.ToLower(label), punycodePrefix)
+	if !found {
+		return label, nil
+	}
+	decoded, err := decodePunycode(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	nonASCII := false
+	for _, char := range decoded {
+		if !validLabelRune(char) {
+			return "", fmt.Errorf("label %q encodes the invalid character %U", label, char)
+		}
+		nonASCII = nonASCII || char > 0x7f
+	}
+	if !nonASCII {
+		return "", fmt.Errorf("label %q does not encode any non ASCII character", label)
+	}
+	return decoded, nil
+}
+
+// validLabelRune reports whether the character can be part of the
+// Unicode form of an internationalized label. This approximates the
+// PVALID code points of IDNA2008 (RFC 5892): lowercase letters, digits,
+// combining marks and the hyphen. Control characters, spaces, symbols,
+// punctuation and uppercase letters are rejected.
+func validLabelRune(char rune) bool {
+	if char <= 0x7f {
+		return (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '-'
+	}
+	if unicode.IsUpper(char) || unicode.IsTitle(char) {
+		return false
+	}
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || unicode.In(char, unicode.Mn, unicode.Mc)
+}
+
+// decodeHostname returns the Unicode form of a host name
+func decodeHostname(host string) (string, error) {
+	labels := strings.Split(host, ".")
+	for index, label := range labels {
+		decoded, err := decodeLabel(label)
+		if err != nil {
+			return "", err
+		}
+		labels[index] = decoded
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// decodePunycode implements the decoding procedure of RFC 3492
+func decodePunycode(encoded string) (string, error) {
+	invalid := fmt.Errorf("invalid punycode %q", encoded)
+
+	output := []rune{}
+	if delimiter := strings.LastIndexByte(encoded, '-'); delimiter == 0 {
+		// The delimiter is only written after at least one basic code
+		// point
+		return "", invalid
+	} else if delimiter > 0 {
+		for _, char := range encoded[:delimiter] {
+			if char > 0x7f {
+				return "", invalid
+			}
+			output = append(output, char)
+		}
+		encoded = encoded[delimiter+1:]
+	}
+
+	n, i, bias := punycodeInitialN, int32(0), punycodeInitialBias
+	for position := 0; position < len(encoded); {
+		oldI, weight := i, int32(1)
+		for k := punycodeBase; ; k += punycodeBase {
+			if position == len(encoded) {
+				return "", invalid
+			}
+			digit, ok := decodePunycodeDigit(encoded[position])
+			if !ok {
+				return "", invalid
+			}
+			position++
+
+			if int64(digit)*int64(weight) > int64(math.MaxInt32-i) {
+				return "", invalid
+			}
+			i += digit * weight
+
+			threshold := k - bias
+			if k <= bias {
+				threshold = punycodeTMin
+			} else if k >= bias+punycodeTMax {
+				threshold = punycodeTMax
+			}
+			if digit < threshold {
+				break
+			}
+			if int64(weight)*int64(punycodeBase-threshold) > math.MaxInt32 {
+				return "", invalid
+			}
+			weight *= punycodeBase - threshold
+		}
+
+		length := int32(len(output) + 1)
+		if length > punycodeMaxDecodedLength {
+			return "", invalid
+		}
+		bias = adaptPunycodeBias(i-oldI, length, oldI == 0)
+		n += i / length
+		i %= length
+		if n > utf8.MaxRune || !utf8.ValidRune(n) {
+			return "", invalid
+		}
+
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = n
+		i++
+	}
+
+	return string(output), nil
+}
+
+func decodePunycodeDigit(char byte) (int32, bool) {
+	switch {
+	case char >= '0' && char <= '9':
+		return int32(char-'0') + 26, true
+	case char >= 'a' && char <= 'z':
+		return int32(char - 'a'), true
+	case char >= 'A' && char <= 'Z':
+		return int32(char - 'A'), true
+	}
+	return 0, false
+}
+
+func adaptPunycodeBias(delta, length int32, firstTime bool) int32 {
+	if firstTime {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / length
+
+	k := int32(0)
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
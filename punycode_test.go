@@ -0,0 +1,136 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDecodeHostname(t *testing.T) {
+	decoded := map[string]string{
+		"xn--80ak6aa92e.com":     "аррӏе.com",
+		"xn--mnchen-3ya.de":      "münchen.de",
+		"api.xn--bcher-kva.com":  "api.bücher.com",
+		"xn--fiqs8s.example.com": "中国.example.com",
+		"example.com":            "example.com",
+	}
+	for host, expected := range decoded {
+		result, err := decodeHostname(host)
+		if err != nil {
+			t.Errorf("Unexpected error decoding %s: %+v", host, err)
+		}
+		if result != expected {
+			t.Errorf("Decoding %s: expected %s, got %s", host, expected, result)
+		}
+	}
+}
+
+func TestDecodeInvalidHostname(t *testing.T) {
+	invalid := []string{
+		"xn--abc-.com",
+		"xn--.com",
+		"xn--a-ecp!.com",
+		"xn--99999999999.com",
+		"xn--a.com",
+		"xn--7ba.com",
+		"xn--mnchen-3ya.xn--a.de",
+	}
+	for _, host := range invalid {
+		if result, err := decodeHostname(host); err == nil {
+			t.Errorf("Expected error decoding %s, got %s", host, result)
+		}
+	}
+}
+
+// Sample strings of RFC 3492 section 7.1, with errata 3026. The encoded
+// forms keep the uppercase letters used by the RFC as case annotations,
+// which are decoded as the basic code points they are.
+var punycodeSamples = []struct {
+	encoded, decoded string
+}{
+	// (A) Arabic (Egyptian)
+	{"egbpdaj6bu4bxfgehfvwxn", "\u0644\u064A\u0647\u0645\u0627\u0628\u062A\u0643\u0644\u0645\u0648\u0634\u0639\u0631\u0628\u064A\u061F"},
+	// (B) Chinese (simplified)
+	{"ihqwcrb4cv8a8dqg056pqjye", "\u4ED6\u4EEC\u4E3A\u4EC0\u4E48\u4E0D\u8BF4\u4E2D\u6587"},
+	// (C) Chinese (traditional)
+	{"ihqwctvzc91f659drss3x8bo0yb", "\u4ED6\u5011\u7232\u4EC0\u9EBD\u4E0D\u8AAA\u4E2D\u6587"},
+	// (D) Czech
+	{"Proprostnemluvesky-uyb24dma41a", "Pro\u010Dprost\u011Bnemluv\u00ED\u010Desky"},
+	// (E) Hebrew
+	{"4dbcagdahymbxekheh6e0a7fei0b", "\u05DC\u05DE\u05D4\u05D4\u05DD\u05E4\u05E9\u05D5\u05D8\u05DC\u05D0\u05DE\u05D3\u05D1\u05E8\u05D9\u05DD\u05E2\u05D1\u05E8\u05D9\u05EA"},
+	// (F) Hindi (Devanagari)
+	{"i1baa7eci9glrd9b2ae1bj0hfcgg6iyaf8o0a1dig0cd", "\u092F\u0939\u0932\u094B\u0917\u0939\u093F\u0928\u094D\u0926\u0940\u0915\u094D\u092F\u094B\u0902\u0928\u0939\u0940\u0902\u092C\u094B\u0932\u0938\u0915\u0924\u0947\u0939\u0948\u0902"},
+	// (G) Japanese (kanji and hiragana)
+	{"n8jok5ay5dzabd5bym9f0cm5685rrjetr6pdxa", "\u306A\u305C\u307F\u3093\u306A\u65E5\u672C\u8A9E\u3092\u8A71\u3057\u3066\u304F\u308C\u306A\u3044\u306E\u304B"},
+	// (H) Korean (Hangul syllables)
+	{"989aomsvi5e83db1d2a355cv1e0vak1dwrv93d5xbh15a0dt30a5jpsd879ccm6fea98c", "\uC138\uACC4\uC758\uBAA8\uB4E0\uC0AC\uB78C\uB4E4\uC774\uD55C\uAD6D\uC5B4\uB97C\uC774\uD574\uD55C\uB2E4\uBA74\uC5BC\uB9C8\uB098\uC88B\uC744\uAE4C"},
+	// (I) Russian (Cyrillic)
+	{"b1abfaaepdrnnbgefbadotcwatmq2g4l", "\u043F\u043E\u0447\u0435\u043C\u0443\u0436\u0435\u043E\u043D\u0438\u043D\u0435\u0433\u043E\u0432\u043E\u0440\u044F\u0442\u043F\u043E\u0440\u0443\u0441\u0441\u043A\u0438"},
+	// (J) Spanish
+	{"PorqunopuedensimplementehablarenEspaol-fmd56a", "Porqu\u00E9nopuedensimplementehablarenEspa\u00F1ol"},
+	// (K) Vietnamese
+	{"TisaohkhngthchnitingVit-kjcr8268qyxafd2f1b9g", "T\u1EA1isaoh\u1ECDkh\u00F4ngth\u1EC3ch\u1EC9n\u00F3iti\u1EBFngVi\u1EC7t"},
+	// (L) 3<nen>B<gumi><kinpachi><sensei>
+	{"3B-ww4c5e180e575a65lsy2b", "3\u5E74B\u7D44\u91D1\u516B\u5148\u751F"},
+	// (M) <amuro><namie>-with-SUPER-MONKEYS
+	{"-with-SUPER-MONKEYS-pc58ag80a8qai00g7n9n", "\u5B89\u5BA4\u5948\u7F8E\u6075-with-SUPER-MONKEYS"},
+	// (N) Hello-Another-Way-<sorezore><no><basho>
+	{"Hello-Another-Way--fc4qua05auwb3674vfr0b", "Hello-Another-Way-\u305D\u308C\u305E\u308C\u306E\u5834\u6240"},
+	// (O) <hitotsu><yane><no><shita>2
+	{"2-u9tlzr9756bt3uc0v", "\u3072\u3068\u3064\u5C4B\u6839\u306E\u4E0B2"},
+	// (P) Maji<de>Koi<suru>5<byou><mae>
+	{"MajiKoi5-783gue6qz075azm5e", "Maji\u3067Koi\u3059\u308B5\u79D2\u524D"},
+	// (Q) <pafii>de<runba>
+	{"de-jg4avhby1noc0d", "\u30D1\u30D5\u30A3\u30FCde\u30EB\u30F3\u30D0"},
+	// (R) <sono><supiido><de>
+	{"d9juau41awczczp", "\u305D\u306E\u30B9\u30D4\u30FC\u30C9\u3067"},
+	// (S) -> $1.00 <-
+	{"-> $1.00 <--", "-> $1.00 <-"},
+}
+
+func TestDecodePunycode(t *testing.T) {
+	for _, sample := range punycodeSamples {
+		result, err := decodePunycode(sample.encoded)
+		if err != nil {
+			t.Errorf("Unexpected error decoding %q: %+v", sample.encoded, err)
+		}
+		if result != sample.decoded {
+			t.Errorf("Decoding %q: expected %q, got %q", sample.encoded, sample.decoded, result)
+		}
+	}
+
+	invalid := []string{
+		"-",
+		"-abc",
+		"foo\x00bar",
+		"foo#bar",
+		"foo\u00A3bar",
+		"9",
+		"99999a",
+		"9999999999a",
+	}
+	for _, encoded := range invalid {
+		if result, err := decodePunycode(encoded); err == nil {
+			t.Errorf("Expected error decoding %q, got %q", encoded, result)
+		}
+	}
+}
+
+func FuzzDecodePunycode(f *testing.F) {
+	for _, sample := range punycodeSamples {
+		f.Add(sample.encoded)
+	}
+	f.Fuzz(func(t *testing.T, encoded string) {
+		result, err := decodePunycode(encoded)
+		if err != nil {
+			return
+		}
+		if !utf8.ValidString(result) {
+			t.Errorf("Decoding %q returned the invalid UTF-8 string %q", encoded, result)
+		}
+		// Only the basic code points can make the output longer than a
+		// label
+		if length := utf8.RuneCountInString(result); length > len(encoded) && length > punycodeMaxDecodedLength {
+			t.Errorf("Decoding %q returned %d characters", encoded, length)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// defaultReservedHostSuffixes are the special use domains of RFC 6761
+// and RFC 8375, together with the suffixes commonly used by cluster
+// internal names. None of them can be reached from outside of the
+// cluster, hence they are never expected inside of an Ingress.
+var defaultReservedHostSuffixes = []string{
+	"localhost",
+	"localdomain",
+	"local",
+	"cluster.local",
+	"svc",
+	"internal",
+	"home.arpa",
+	"in-addr.arpa",
+	"ip6.arpa",
+	"invalid",
+	"onion",
+}
+
+// reservedHostSuffix returns the longest reserved suffix the host is
+// under
+func reservedHostSuffix(host string, suffixes []string) (string, bool) {
+	longest := ""
+	for _, suffix := range suffixes {
+		suffix = strings.TrimPrefix(suffix, ".")
+		if hostUnderDomain(host, suffix) && len(suffix) > len(longest) {
+			longest = suffix
+		}
+	}
+	return longest, longest != ""
+}
+
+// The hosts under one of the ReservedHostSuffixes are rejected, and so
+// are the hosts under one of the default reserved suffixes, unless
+// DisableReservedHostsCheck is enabled
+func checkReservedHosts(hosts []ingressHost, settings *Settings) error {
+	suffixes := settings.ReservedHostSuffixes
+	if !settings.DisableReservedHostsCheck {
+		suffixes = slices.Concat(defaultReservedHostSuffixes, settings.ReservedHostSuffixes)
+	}
+	if len(suffixes) == 0 {
+		return nil
+	}
+
+	violations := []string{}
+	for _, host := range hosts {
+		if suffix, found := reservedHostSuffix(host.name, suffixes); found {
+			violations = append(violations, fmt.Sprintf("host %s is under the reserved suffix %q", host, suffix))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReservedHostSuffix(t *testing.T) {
+	reserved := map[string]string{
+		"localhost":                     "localhost",
+		"api.default.svc.cluster.local": "cluster.local",
+		"printer.local":                 "local",
+		"metadata.google.internal":      "internal",
+		"*.Internal":                    "internal",
+		"router.home.arpa":              "home.arpa",
+		"api.corp.example":              "corp.example",
+	}
+	suffixes := slices.Concat(defaultReservedHostSuffixes, []string{".corp.example"})
+	for host, expected := range reserved {
+		suffix, found := reservedHostSuffix(host, suffixes)
+		if !found || suffix != expected {
+			t.Errorf("Host %s: expected the reserved suffix %q, got %q", host, expected, suffix)
+		}
+	}
+
+	for _, host := range []string{"foo.bar.com", "internal.bar.com", "notlocal"} {
+		if suffix, found := reservedHostSuffix(host, suffixes); found {
+			t.Errorf("Host %s should not be reserved, got %q", host, suffix)
+		}
+	}
+}
+
+func TestReservedHostsAreRejected(t *testing.T) {
+	response := validateFixture(t, "test_data/reserved-hosts.json", `{}`)
+	expectRejectedWith(t, response,
+		`host "api.default.svc.cluster.local" (spec.rules[1].host) is under the reserved suffix "cluster.local"; `+
+			`host "*.internal" (spec.rules[2].host) is under the reserved suffix "internal"; `+
+			`host "localhost" (spec.tls[0].hosts[0]) is under the reserved suffix "localhost"`)
+
+	response = validateFixture(t, "test_data/reserved-hosts.json", `{"disableReservedHostsCheck": true}`)
+	expectAccepted(t, response)
+}
+
+func TestAdditionalReservedHostSuffixes(t *testing.T) {
+	response := validateFixture(t, "test_data/ingress-wildcard.json", `{"reservedHostSuffixes": ["foo.com"]}`)
+	expectRejectedWith(t, response, `host "*.foo.com" (spec.rules[1].host) is under the reserved suffix "foo.com"`)
+
+	response = validateFixture(t, "test_data/ingress-wildcard.json", `{"reservedHostSuffixes": ["corp.example"]}`)
+	expectAccepted(t, response)
+}
+
+func TestDisableReservedHostsCheckKeepsConfiguredSuffixes(t *testing.T) {
+	response := validateFixture(t, "test_data/reserved-hosts.json", `{
+		"disableReservedHostsCheck": true,
+		"disablePublicSuffixCheck": true,
+		"reservedHostSuffixes": ["internal"]
+	}`)
+	expectRejectedWith(t, response, `host "*.internal" (spec.rules[2].host) is under the reserved suffix "internal"`)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kubewarden/gjson"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+const (
+	// Placeholder of TlsSecretNameTemplate replaced by the TLS host
+	secretNameTemplateHost = "{{host}}"
+
+	tlsSecretType = "kubernetes.io/tls"
+)
+
+// tlsEntry is an entry of the spec.tls list of an Ingress
+type tlsEntry struct {
+	index      int
+	hosts      []string
+	secretName string
+}
+
+func (e tlsEntry) field() string {
+	return fmt.Sprintf("spec.tls[%d]", e.index)
+}
+
+func parseTlsEntries(payload []byte) []tlsEntry {
+	entries := []tlsEntry{}
+
+	data := gjson.GetBytes(payload, "request.object.spec.tls")
+	for index, entry := range data.Array() {
+		hosts := []string{}
+		for _, host := range entry.Get("hosts").Array() {
+			hosts = append(hosts, host.String())
+		}
+		entries = append(entries, tlsEntry{
+			index:      index,
+			hosts:      hosts,
+			secretName: entry.Get("secretName").String(),
+		})
+	}
+
+	return entries
+}
+
+// renderSecretNameTemplate returns the secret name expected for the
+// given host. The wildcard label of a host is rendered as `wildcard`,
+// because `*` is not allowed inside of secret names.
+func renderSecretNameTemplate(template, host string) string {
+	if suffix, isWildcard := strings.CutPrefix(host, "*."); isWildcard {
+		host = "wildcard." + suffix
+	}
+	return strings.ReplaceAll(template, secretNameTemplateHost, strings.ToLower(host))
+}
+
+// Every TLS entry must reference a secret when RequireTlsSecretName is
+// enabled. The name of the secret must match the TlsSecretNamePattern
+// regular expression and the TlsSecretNameTemplate, when they are set.
+func checkTlsSecretNames(payload []byte, settings *Settings) error {
+	if !settings.RequireTlsSecretName && settings.TlsSecretNamePattern == "" && settings.TlsSecretNameTemplate == "" {
+		return nil
+	}
+
+	var pattern *regexp.Regexp
+	if settings.TlsSecretNamePattern != "" {
+		var err error
+		pattern, err = compileFullMatch(settings.TlsSecretNamePattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	violations := []string{}
+	for _, entry := range parseTlsEntries(payload) {
+		if entry.secretName == "" {
+			if settings.RequireTlsSecretName {
+				violations = append(violations, fmt.Sprintf("%s: secretName is required", entry.field()))
+			}
+			continue
+		}
+
+		if pattern != nil && !pattern.MatchString(entry.secretName) {
+			violations = append(violations,
+				fmt.Sprintf("%s: secretName %q does not match the pattern %q",
+					entry.field(), entry.secretName, settings.TlsSecretNamePattern))
+		}
+
+		if settings.TlsSecretNameTemplate != "" {
+			if err := checkSecretNameTemplate(entry, settings.TlsSecretNameTemplate); err != nil {
+				violations = append(violations, fmt.Sprintf("%s: %s", entry.field(), err))
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
+
+// The secret name must be the template rendered with one of the hosts
+// of the TLS entry
+func checkSecretNameTemplate(entry tlsEntry, template string) error {
+	if len(entry.hosts) == 0 {
+		return errors.New("the expected secretName cannot be computed, hosts is empty")
+	}
+
+	expected := []string{}
+	for _, host := range entry.hosts {
+		name := renderSecretNameTemplate(template, host)
+		if name == entry.secretName {
+			return nil
+		}
+		expected = append(expected, fmt.Sprintf("%q", name))
+	}
+
+	return fmt.Errorf("secretName %q does not match the expected one: %s",
+		entry.secretName, strings.Join(expected, " or "))
+}
+
+// Every secret referenced by the TLS section must exist inside of the
+// namespace of the Ingress and must be of the kubernetes.io/tls type.
+// When VerifyTlsCertificates is enabled or a trusted CA bundle is
+// provided, the certificates held by the secrets are checked too.
+// This requires the policy to be context aware.
+func checkTlsSecrets(payload []byte, namespace string, settings *Settings) error {
+	if !settings.VerifyTlsSecrets && !settings.inspectTlsCertificates() {
+		return nil
+	}
+
+	var roots *x509.CertPool
+	if settings.TrustedCABundle != "" {
+		var err error
+		roots, err = parseTrustedCABundle(settings.TrustedCABundle)
+		if err != nil {
+			return fmt.Errorf("invalid trustedCABundle: %w", err)
+		}
+	}
+
+	secrets := map[string]*corev1.Secret{}
+	violations := []string{}
+	for _, entry := range parseTlsEntries(payload) {
+		if entry.secretName == "" {
+			continue
+		}
+
+		secret, found := secrets[entry.secretName]
+		if !found {
+			var err error
+			secret, err = getSecret(namespace, entry.secretName)
+			if err != nil {
+				violations = append(violations,
+					fmt.Sprintf("%s: cannot find secret %q inside of the %q namespace: %s",
+						entry.field(), entry.secretName, namespace, err))
+				continue
+			}
+			secrets[entry.secretName] = secret
+		}
+
+		if secret.Type != tlsSecretType {
+			violations = append(violations,
+				fmt.Sprintf("%s: secret %q is of type %q, expected %q",
+					entry.field(), entry.secretName, secret.Type, tlsSecretType))
+			continue
+		}
+
+		if settings.inspectTlsCertificates() {
+			if err := checkTlsSecretCertificate(entry, secret, roots, settings); err != nil {
+				violations = append(violations, fmt.Sprintf("%s: %s", entry.field(), err))
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(violations, "; "))
+}
+
+func checkTlsSecretCertificate(entry tlsEntry, secret *corev1.Secret, roots *x509.CertPool, settings *Settings) error {
+	chain, err := parseCertificateChain(secret)
+	if err != nil {
+		return fmt.Errorf("secret %q: %w", entry.secretName, err)
+	}
+
+	if settings.checkTlsCertificateKeys() {
+		if err := checkCertificateKeys(entry.secretName, chain, settings); err != nil {
+			return err
+		}
+	}
+	if roots != nil {
+		if err := checkCertificateChain(entry.secretName, chain, roots); err != nil {
+			return err
+		}
+	}
+	if settings.VerifyTlsCertificates {
+		return checkTlsCertificate(entry, chain, settings)
+	}
+	return nil
+}
+
+// compileFullMatch compiles a regular expression that must match the
+// whole string
+func compileFullMatch(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
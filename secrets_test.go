@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+func TestTlsSecretNameIsRequired(t *testing.T) {
+	response := validateFixture(t, "test_data/tls-secret-names.json", `{"requireTLSSecretName": true}`)
+	expectRejectedWith(t, response, "spec.tls[2]: secretName is required")
+
+	response = validateFixture(t, "test_data/single-backend-with-tls-termination.json", `{"requireTLSSecretName": true}`)
+	expectAccepted(t, response)
+}
+
+func TestTlsSecretNamePattern(t *testing.T) {
+	response := validateFixture(t, "test_data/tls-secret-names.json", `{"tlsSecretNamePattern": "foo\\..*"}`)
+	expectAccepted(t, response)
+
+	response = validateFixture(t, "test_data/tls-secret-names.json", `{"tlsSecretNamePattern": "bar\\..*"}`)
+	expectRejectedWith(t, response, `spec.tls[0]: secretName "foo.bar.com-tls" does not match the pattern "bar\\..*"`)
+}
+
+func TestTlsSecretNameTemplate(t *testing.T) {
+	response := validateFixture(t, "test_data/tls-secret-names.json", `{"tlsSecretNameTemplate": "{{host}}-tls"}`)
+	expectRejectedWith(t, response, `spec.tls[1]: secretName "foo.bar.com-tls" does not match the expected one: "api.bar.com-tls"`)
+
+	response = validateFixture(t, "test_data/single-backend-with-tls-termination.json", `{"tlsSecretNameTemplate": "testsecret-tls-{{host}}"}`)
+	expectRejectedWith(t, response, `"testsecret-tls-https-example.foo.com"`)
+}
+
+func TestRenderSecretNameTemplate(t *testing.T) {
+	name := renderSecretNameTemplate("{{host}}-tls", "*.Example.com")
+	if name != "wildcard.example.com-tls" {
+		t.Errorf("Wrong secret name rendered: %s", name)
+	}
+}
+
+func TestTlsSecretsAreVerified(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): corev1.Secret{Type: "kubernetes.io/tls"},
+			resourceKey("Secret", "team-a", "api-tls"): corev1.Secret{Type: "Opaque"},
+		},
+	})
+
+	response := validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSSecrets": true}`)
+	expectRejectedWith(t, response, `spec.tls[1]: secret "api-tls" is of type "Opaque", expected "kubernetes.io/tls"`)
+
+	response = validateFixture(t, "test_data/tls-secrets.json", `{}`)
+	expectAccepted(t, response)
+}
+
+func TestValidTlsSecretsAreAccepted(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): corev1.Secret{Type: "kubernetes.io/tls"},
+			resourceKey("Secret", "team-a", "api-tls"): corev1.Secret{Type: "kubernetes.io/tls"},
+		},
+	})
+
+	response := validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSSecrets": true}`)
+	expectAccepted(t, response)
+}
+
+func TestMissingTlsSecretsAreRejected(t *testing.T) {
+	useFakeHost(t, &fakeWapcClient{
+		resources: map[string]interface{}{
+			resourceKey("Secret", "team-a", "foo-tls"): corev1.Secret{Type: "kubernetes.io/tls"},
+			resourceKey("Secret", "team-b", "api-tls"): corev1.Secret{Type: "kubernetes.io/tls"},
+		},
+	})
+
+	response := validateFixture(t, "test_data/tls-secrets.json", `{"verifyTLSSecrets": true}`)
+	expectRejectedWith(t, response, `spec.tls[1]: cannot find secret "api-tls" inside of the "team-a" namespace`)
+}
@@ -2,16 +2,182 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	kubewarden "github.com/kubewarden/policy-sdk-go"
 	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
 )
 
+// Action defines how the policy reacts to a finding that can be
+// tolerated by the user
+type Action string
+
+const (
+	ActionReject Action = "reject"
+	ActionWarn   Action = "warn"
+	ActionAccept Action = "accept"
+)
+
+// validate checks the action configured for the given setting is one
+// of the known ones
+func (a Action) validate(setting string) error {
+	if a == ActionReject || a == ActionWarn || a == ActionAccept {
+		return nil
+	}
+	return fmt.Errorf("invalid %s %q, must be one of: reject, warn, accept", setting, a)
+}
+
+// report handles the finding described by msg: it is ignored, logged as
+// a warning or turned into an error, depending on the action
+func (a Action) report(msg string) error {
+	switch a {
+	case ActionAccept:
+		return nil
+	case ActionWarn:
+		logWarning(msg)
+		return nil
+	default:
+		return errors.New(msg)
+	}
+}
+
+// TlsMode defines how the hosts of the rules are compared with the
+// ones of the TLS section
+type TlsMode string
+
+const (
+	// Every rule host must have TLS enabled, every TLS host must be
+	// used by a rule
+	TlsModeExact TlsMode = "exact"
+	// Every rule host must have TLS enabled
+	TlsModeCoverage TlsMode = "coverage"
+)
+
 type Settings struct {
-	RequireTls bool               `json:"requireTLS"`
-	AllowPorts mapset.Set[uint64] `json:"allowPorts"`
-	DenyPorts  mapset.Set[uint64] `json:"denyPorts"`
+	RequireTls bool    `json:"requireTLS"`
+	TlsMode    TlsMode `json:"tlsMode"`
+	// What to do with the TLS hosts that are not used by any rule with
+	// the coverage TLS mode, the exact one always rejects them. Defaults
+	// to reject with the exact TLS mode, to accept otherwise
+	OrphanTlsHostsAction Action `json:"orphanTLSHostsAction"`
+	// What to do with the rules without a host when TLS is required
+	HostlessRulesTlsAction Action `json:"hostlessRulesTLSAction"`
+	// Every TLS entry must reference a secret
+	RequireTlsSecretName bool `json:"requireTLSSecretName"`
+	// Regular expression that must match the whole TLS secret names
+	TlsSecretNamePattern string `json:"tlsSecretNamePattern"`
+	// Template of the TLS secret names, `{{host}}` is replaced by the
+	// TLS host
+	TlsSecretNameTemplate string `json:"tlsSecretNameTemplate"`
+	// Look up the TLS secrets to ensure they exist and are of the
+	// kubernetes.io/tls type
+	VerifyTlsSecrets bool `json:"verifyTLSSecrets"`
+	// Look up the TLS secrets to ensure their certificates cover the
+	// TLS hosts and are not expired
+	VerifyTlsCertificates bool `json:"verifyTLSCertificates"`
+	// Minimum number of days the TLS certificates must still be valid
+	MinCertificateValidityDays int `json:"minCertificateValidityDays"`
+	// PEM encoded trust anchors the TLS certificate chains must lead to
+	TrustedCABundle string `json:"trustedCABundle"`
+	// Minimum size of the keys used by the TLS certificates, zero means
+	// the default one. Setting them enforces the key policy even without
+	// a trusted CA bundle
+	MinRSAKeySize   int `json:"minRSAKeySize"`
+	MinECDSAKeySize int `json:"minECDSAKeySize"`
+
+	// Every Ingress must set its ingress class
+	RequireIngressClass bool `json:"requireIngressClass"`
+	// Glob patterns of the ingress classes that can be used
+	AllowedIngressClasses []string `json:"allowedIngressClasses"`
+	// Glob patterns of the ingress classes that can be used, indexed by
+	// namespace glob pattern. They take precedence over
+	// AllowedIngressClasses
+	NamespaceIngressClasses map[string][]string `json:"namespaceIngressClasses"`
+
+	// Maximum size of the Ingress, zero means no limit
+	MaxHosts        int `json:"maxHosts"`
+	MaxRules        int `json:"maxRules"`
+	MaxPathsPerRule int `json:"maxPathsPerRule"`
+	MaxPaths        int `json:"maxPaths"`
+	MaxTlsEntries   int `json:"maxTLSEntries"`
+
+	// Path types that can be used by the rules
+	AllowedPathTypes []string `json:"allowedPathTypes"`
+	// Every path must be absolute, without parent references, empty
+	// segments, control characters and, for Prefix and Exact paths,
+	// regular expression metacharacters
+	ValidatePaths bool `json:"validatePaths"`
+	// What to do with the paths that have the same host, path and type
+	// of another path. Defaults to warn
+	DuplicatePathsAction Action `json:"duplicatePathsAction"`
+	// What to do with the paths that cannot be reached because of an
+	// equivalent path. Defaults to warn
+	ShadowedPathsAction Action `json:"shadowedPathsAction"`
+	// Glob patterns of the ingress classes whose paths must use only a
+	// safe set of characters
+	StrictPathValidationIngressClasses []string `json:"strictPathValidationIngressClasses"`
+	// Ingress class handling the Ingresses that do not set one. When
+	// empty, these Ingresses always get the strict path validation
+	DefaultIngressClass string `json:"defaultIngressClass"`
+
+	// Every rule must have a host
+	RequireHost bool `json:"requireHost"`
+	// Every host must be a valid, lowercase, DNS name
+	ValidateHostnames bool `json:"validateHostnames"`
+	// Do not reject the hosts under the built-in reserved suffixes
+	DisableReservedHostsCheck bool `json:"disableReservedHostsCheck"`
+	// Suffixes of the hosts that cannot be used, in addition to the
+	// built-in reserved ones
+	ReservedHostSuffixes []string `json:"reservedHostSuffixes"`
+	// Do not reject the hosts that are public suffixes, or wildcards
+	// covering a public suffix
+	DisablePublicSuffixCheck bool `json:"disablePublicSuffixCheck"`
+	// Handle the top level domains that are not part of the Public Suffix
+	// List as public suffixes, as the implicit `*` rule of the list does
+	RejectUnlistedTopLevelDomains bool `json:"rejectUnlistedTopLevelDomains"`
+	// Registrable domains the hosts must belong to
+	AllowedRegistrableDomains []string `json:"allowedRegistrableDomains"`
+	// Domains the hosts must not look like, unless they are under them
+	ProtectedDomains []string `json:"protectedDomains"`
+	// Hosts that can look like one of the protected domains
+	ProtectedDomainsExceptions []string `json:"protectedDomainsExceptions"`
+	// Glob patterns of the hosts that can be used
+	AllowedHosts []string `json:"allowedHosts"`
+	// Glob patterns of the hosts that cannot be used
+	DeniedHosts []string `json:"deniedHosts"`
+	// Domains owned by the namespaces, indexed by namespace glob pattern
+	NamespaceDomains map[string][]string `json:"namespaceDomains"`
+	// Annotation of the Namespace of the Ingress that holds the glob
+	// patterns of the hosts that can be used
+	NamespaceAllowedHostsAnnotation string `json:"namespaceAllowedHostsAnnotation"`
+
+	// Reject the wildcard hosts of the rules, unless the Ingress is
+	// inside of one of the allowed namespaces or the requester belongs
+	// to one of the allowed groups
+	DenyWildcardHosts              bool     `json:"denyWildcardHosts"`
+	WildcardHostsAllowedNamespaces []string `json:"wildcardHostsAllowedNamespaces"`
+	WildcardHostsAllowedGroups     []string `json:"wildcardHostsAllowedGroups"`
+	// Reject the host and path pairs already used by the Ingresses of
+	// other namespaces
+	DenyCrossNamespaceCollisions bool `json:"denyCrossNamespaceCollisions"`
+	// Pairs of namespaces that are allowed to share host and path pairs
+	AllowedCollisionNamespaces [][]string `json:"allowedCollisionNamespaces"`
+	// Path prefixes of the shared hosts and the glob patterns of the
+	// namespaces owning them, indexed by host
+	PathOwnership map[string]map[string][]string `json:"pathOwnership"`
+
+	AllowPorts     PortSet            `json:"allowPorts"`
+	DenyPorts      PortSet            `json:"denyPorts"`
+	AllowPortNames mapset.Set[string] `json:"allowPortNames"`
+	DenyPortNames  mapset.Set[string] `json:"denyPortNames"`
+	// What to do with named ports when they cannot be checked because
+	// only numeric port lists are provided
+	NamedPortsAction   Action `json:"namedPortsAction"`
+	DenyDefaultBackend bool   `json:"denyDefaultBackend"`
 }
 
 func NewSettingsFromValidationReq(validationReq *kubewarden_protocol.ValidationRequest) (Settings, error) {
@@ -25,29 +191,196 @@ func NewSettingsFromValidationReq(validationReq *kubewarden_protocol.ValidationR
 }
 
 // The AllowPorts and DenyPorts should not have any
-// element in common
+// overlapping range, AllowPortNames and DenyPortNames should not have
+// any element in common
 func (s *Settings) Valid() bool {
-	common := s.AllowPorts.Intersect(s.DenyPorts)
-	return common.Cardinality() == 0
+	return s.Validate() == nil
+}
+
+// Validate returns an error describing the first inconsistency found
+// inside of the settings
+func (s *Settings) Validate() error {
+	for _, allowed := range s.AllowPorts {
+		for _, denied := range s.DenyPorts {
+			if allowed.Overlaps(denied) {
+				return fmt.Errorf("no port can be allowed and denied at the same time: %s overlaps with %s", allowed, denied)
+			}
+		}
+	}
+	if s.AllowPortNames.Intersect(s.DenyPortNames).Cardinality() != 0 {
+		return errors.New("no named port can be allowed and denied at the same time")
+	}
+	if s.TlsMode != TlsModeExact && s.TlsMode != TlsModeCoverage {
+		return fmt.Errorf("invalid tlsMode %q, must be one of: exact, coverage", s.TlsMode)
+	}
+	if err := s.OrphanTlsHostsAction.validate("orphanTLSHostsAction"); err != nil {
+		return err
+	}
+	if s.TlsMode == TlsModeExact && s.OrphanTlsHostsAction != ActionReject {
+		return fmt.Errorf("orphanTLSHostsAction %q cannot be used with the exact tlsMode, which always rejects the orphan TLS hosts", s.OrphanTlsHostsAction)
+	}
+	if err := s.HostlessRulesTlsAction.validate("hostlessRulesTLSAction"); err != nil {
+		return err
+	}
+	if s.TlsSecretNamePattern != "" {
+		if _, err := compileFullMatch(s.TlsSecretNamePattern); err != nil {
+			return fmt.Errorf("invalid tlsSecretNamePattern: %w", err)
+		}
+	}
+	if s.TlsSecretNameTemplate != "" && !strings.Contains(s.TlsSecretNameTemplate, secretNameTemplateHost) {
+		return fmt.Errorf("invalid tlsSecretNameTemplate %q, it must contain %s", s.TlsSecretNameTemplate, secretNameTemplateHost)
+	}
+	if s.MinCertificateValidityDays < 0 {
+		return errors.New("minCertificateValidityDays cannot be negative")
+	}
+	if s.TrustedCABundle != "" {
+		if _, err := parseTrustedCABundle(s.TrustedCABundle); err != nil {
+			return fmt.Errorf("invalid trustedCABundle: %w", err)
+		}
+	}
+	if s.MinRSAKeySize < 0 || s.MinECDSAKeySize < 0 {
+		return errors.New("minRSAKeySize and minECDSAKeySize cannot be negative")
+	}
+	for _, pattern := range s.AllowedIngressClasses {
+		if pattern == "" {
+			return errors.New("allowedIngressClasses cannot have empty patterns")
+		}
+	}
+	for namespace, patterns := range s.NamespaceIngressClasses {
+		if len(patterns) == 0 {
+			return fmt.Errorf("namespace %q must be allowed to use at least one ingress class", namespace)
+		}
+		for _, pattern := range patterns {
+			if pattern == "" {
+				return fmt.Errorf("the ingress classes of namespace %q cannot have empty patterns", namespace)
+			}
+		}
+	}
+	if s.MaxHosts < 0 || s.MaxRules < 0 || s.MaxPathsPerRule < 0 || s.MaxPaths < 0 || s.MaxTlsEntries < 0 {
+		return errors.New("maxHosts, maxRules, maxPathsPerRule, maxPaths and maxTLSEntries cannot be negative")
+	}
+	for _, pathType := range s.AllowedPathTypes {
+		if !slices.Contains(pathTypes, pathType) {
+			return fmt.Errorf("invalid allowedPathTypes entry %q, must be one of: %s", pathType, strings.Join(pathTypes, ", "))
+		}
+	}
+	for _, suffix := range s.ReservedHostSuffixes {
+		if strings.TrimPrefix(suffix, ".") == "" || strings.ContainsAny(suffix, "*?") {
+			return fmt.Errorf("invalid reservedHostSuffixes entry %q, it must be a plain domain name", suffix)
+		}
+	}
+	for _, domain := range s.AllowedRegistrableDomains {
+		if registrable, found := registrableDomain(domain); !found || registrable != strings.ToLower(domain) || strings.ContainsAny(domain, "*?") {
+			return fmt.Errorf("invalid allowedRegistrableDomains entry %q, it must be a registrable domain like example.com", domain)
+		}
+	}
+	for _, domain := range s.ProtectedDomains {
+		if domain == "" || strings.ContainsAny(domain, "*?") {
+			return fmt.Errorf("invalid protectedDomains entry %q, it must be a plain domain name", domain)
+		}
+	}
+	for _, pattern := range append(s.AllowedHosts, s.DeniedHosts...) {
+		if pattern == "" {
+			return errors.New("allowedHosts and deniedHosts cannot have empty patterns")
+		}
+	}
+	for namespace, domains := range s.NamespaceDomains {
+		for _, domain := range domains {
+			if strings.TrimPrefix(domain, ".") == "" || strings.ContainsAny(domain, "*?") {
+				return fmt.Errorf("invalid domain %q owned by namespace %q, it must be a plain domain name", domain, namespace)
+			}
+		}
+	}
+	for host, prefixes := range s.PathOwnership {
+		normalizedPrefixes := map[string]string{}
+		for prefix, namespaces := range prefixes {
+			normalized := strings.Join(pathElements(prefix), "/")
+			if other, found := normalizedPrefixes[normalized]; found {
+				return fmt.Errorf("invalid pathOwnership prefixes %q and %q of host %q, they cover the same paths", other, prefix, host)
+			}
+			normalizedPrefixes[normalized] = prefix
+			if !strings.HasPrefix(prefix, "/") {
+				return fmt.Errorf("invalid pathOwnership prefix %q of host %q, it must be an absolute path", prefix, host)
+			}
+			if len(namespaces) == 0 {
+				return fmt.Errorf("invalid pathOwnership prefix %q of host %q, it must be owned by at least one namespace", prefix, host)
+			}
+		}
+	}
+	for _, pair := range s.AllowedCollisionNamespaces {
+		if len(pair) != 2 {
+			return fmt.Errorf("invalid allowedCollisionNamespaces entry %v, it must be a pair of namespaces", pair)
+		}
+	}
+	if err := s.DuplicatePathsAction.validate("duplicatePathsAction"); err != nil {
+		return err
+	}
+	if err := s.ShadowedPathsAction.validate("shadowedPathsAction"); err != nil {
+		return err
+	}
+	if err := s.NamedPortsAction.validate("namedPortsAction"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// The key policy of the TLS certificates is enforced when a trusted CA
+// bundle is provided or when one of the minimum key sizes is set
+func (s *Settings) checkTlsCertificateKeys() bool {
+	return s.TrustedCABundle != "" || s.MinRSAKeySize != 0 || s.MinECDSAKeySize != 0
+}
+
+// The certificates held by the TLS secrets are inspected when their
+// verification is requested or when their key policy is enforced
+func (s *Settings) inspectTlsCertificates() bool {
+	return s.VerifyTlsCertificates || s.checkTlsCertificateKeys()
 }
 
 func (s *Settings) UnmarshalJSON(data []byte) error {
 	// This is needed becaus golang-set v2.3.0 has a bug that prevents
 	// the correct unmarshalling of ThreadUnsafeSet types.
+	// The set attributes are shadowed by plain slices, all the other
+	// attributes are unmarshalled straight into the Settings instance.
+	type settingsAlias Settings
 	rawSettings := struct {
-		RequireTls bool     `json:"requireTLS"`
-		AllowPorts []uint64 `json:"allowPorts"`
-		DenyPorts  []uint64 `json:"denyPorts"`
-	}{}
+		*settingsAlias
+		AllowPortNames []string `json:"allowPortNames"`
+		DenyPortNames  []string `json:"denyPortNames"`
+	}{
+		settingsAlias: (*settingsAlias)(s),
+	}
 
 	err := json.Unmarshal(data, &rawSettings)
 	if err != nil {
 		return err
 	}
 
-	s.RequireTls = rawSettings.RequireTls
-	s.AllowPorts = mapset.NewThreadUnsafeSet[uint64](rawSettings.AllowPorts...)
-	s.DenyPorts = mapset.NewThreadUnsafeSet[uint64](rawSettings.DenyPorts...)
+	s.AllowPortNames = mapset.NewThreadUnsafeSet[string](rawSettings.AllowPortNames...)
+	s.DenyPortNames = mapset.NewThreadUnsafeSet[string](rawSettings.DenyPortNames...)
+
+	if s.NamedPortsAction == "" {
+		s.NamedPortsAction = ActionReject
+	}
+	if s.HostlessRulesTlsAction == "" {
+		s.HostlessRulesTlsAction = ActionReject
+	}
+	if s.DuplicatePathsAction == "" {
+		s.DuplicatePathsAction = ActionWarn
+	}
+	if s.ShadowedPathsAction == "" {
+		s.ShadowedPathsAction = ActionWarn
+	}
+	if s.TlsMode == "" {
+		s.TlsMode = TlsModeExact
+	}
+	if s.OrphanTlsHostsAction == "" {
+		if s.TlsMode == TlsModeExact {
+			s.OrphanTlsHostsAction = ActionReject
+		} else {
+			s.OrphanTlsHostsAction = ActionAccept
+		}
+	}
 
 	return nil
 }
@@ -59,9 +392,9 @@ func validateSettings(payload []byte) ([]byte, error) {
 		return []byte{}, err
 	}
 
-	if settings.Valid() {
-		return kubewarden.AcceptSettings()
+	if err := settings.Validate(); err != nil {
+		return kubewarden.RejectSettings(kubewarden.Message(err.Error()))
 	}
 
-	return kubewarden.RejectSettings(kubewarden.Message("No port can be allowed and denied at the same time"))
+	return kubewarden.AcceptSettings()
 }
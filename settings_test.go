@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"testing"
 
-	mapset "github.com/deckarep/golang-set/v2"
 	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
 	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
 	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
@@ -21,8 +20,8 @@ func TestParsingSettingsWithAllValuesProvidedFromValidationReq(t *testing.T) {
 
 	expectedSettings := Settings{
 		RequireTls: true,
-		AllowPorts: mapset.NewThreadUnsafeSet[uint64](443),
-		DenyPorts:  mapset.NewThreadUnsafeSet[uint64](80, 8080),
+		AllowPorts: PortSet{{First: 443, Last: 443}},
+		DenyPorts:  PortSet{{First: 80, Last: 80}, {First: 8080, Last: 8080}},
 	}
 
 	validationReqRaw, err := kubewarden_testing.BuildValidationRequest(ingress, &expectedSettings)
@@ -66,8 +65,8 @@ func TestParsingSettingsWithSomeValuesProvided(t *testing.T) {
 
 	expectedSettings := Settings{
 		RequireTls: false,
-		AllowPorts: mapset.NewThreadUnsafeSet[uint64](443),
-		DenyPorts:  mapset.NewThreadUnsafeSet[uint64](),
+		AllowPorts: PortSet{{First: 443, Last: 443}},
+		DenyPorts:  PortSet{},
 	}
 
 	validationReqRaw, err := kubewarden_testing.BuildValidationRequest(ingress, expectedSettings)
@@ -93,7 +92,7 @@ func TestParsingSettingsWithSomeValuesProvided(t *testing.T) {
 		t.Errorf("Missing value from AllowPorts")
 	}
 
-	if settings.DenyPorts.Cardinality() != 0 {
+	if len(settings.DenyPorts) != 0 {
 		t.Errorf("Expecpted DenyPorts to be empty")
 	}
 }
@@ -165,3 +164,326 @@ func TestSettingsAreNotValid(t *testing.T) {
 		t.Errorf("Settings are reported as Valid")
 	}
 }
+
+func TestSettingsWithNamedPortsAreNotValid(t *testing.T) {
+	request := `
+	{
+		"allowPortNames": [ "https" ],
+		"denyPortNames": [ "https", "ssh" ]
+	}
+	`
+	settings := Settings{}
+	err := json.Unmarshal([]byte(request), &settings)
+	if err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+
+	if settings.Valid() != false {
+		t.Errorf("Settings are reported as Valid")
+	}
+}
+
+func TestSettingsWithInvalidNamedPortsAction(t *testing.T) {
+	request := `
+	{
+		"namedPortsAction": "ignore"
+	}
+	`
+	settings := Settings{}
+	err := json.Unmarshal([]byte(request), &settings)
+	if err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+
+	if settings.Valid() != false {
+		t.Errorf("Settings are reported as Valid")
+	}
+}
+
+func TestParsingSettingsWithPortRanges(t *testing.T) {
+	request := `
+	{
+		"allowPorts": [ 443, "8000-8999" ],
+		"denyPorts": [ "22", "9000 - 9100" ]
+	}
+	`
+	settings := Settings{}
+	err := json.Unmarshal([]byte(request), &settings)
+	if err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+
+	for _, port := range []uint64{443, 8000, 8500, 8999} {
+		if !settings.AllowPorts.Contains(port) {
+			t.Errorf("Missing port %v from AllowPorts", port)
+		}
+	}
+	for _, port := range []uint64{444, 7999, 9000} {
+		if settings.AllowPorts.Contains(port) {
+			t.Errorf("Unexpected port %v inside of AllowPorts", port)
+		}
+	}
+
+	portRange, found := settings.DenyPorts.Match(9050)
+	if !found || portRange.String() != "9000-9100" {
+		t.Errorf("Port 9050 should match the 9000-9100 range, got %v", portRange)
+	}
+
+	if settings.Valid() != true {
+		t.Errorf("Settings are not reported as Valid")
+	}
+}
+
+func TestParsingSettingsWithInvalidPortRanges(t *testing.T) {
+	requests := []string{
+		`{"allowPorts": [ "9000-8000" ]}`,
+		`{"allowPorts": [ "80-" ]}`,
+		`{"denyPorts": [ 70000 ]}`,
+		`{"denyPorts": [ "http" ]}`,
+	}
+
+	for _, request := range requests {
+		settings := Settings{}
+		err := json.Unmarshal([]byte(request), &settings)
+		if err == nil {
+			t.Errorf("Expected error when parsing %s", request)
+		}
+	}
+}
+
+func TestSettingsWithOverlappingPortRangesAreNotValid(t *testing.T) {
+	request := `
+	{
+		"allowPorts": [ 443, "8000-8999" ],
+		"denyPorts": [ "8900-9100" ]
+	}
+	`
+	settings := Settings{}
+	err := json.Unmarshal([]byte(request), &settings)
+	if err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+
+	if settings.Valid() != false {
+		t.Errorf("Settings are reported as Valid")
+	}
+}
+
+func TestSettingsTlsModeDefaults(t *testing.T) {
+	settings := Settings{}
+	err := json.Unmarshal([]byte(`{"requireTLS": true}`), &settings)
+	if err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+	if settings.TlsMode != TlsModeExact || settings.OrphanTlsHostsAction != ActionReject {
+		t.Errorf("Wrong defaults for the exact TLS mode: %v, %v", settings.TlsMode, settings.OrphanTlsHostsAction)
+	}
+
+	settings = Settings{}
+	err = json.Unmarshal([]byte(`{"requireTLS": true, "tlsMode": "coverage"}`), &settings)
+	if err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+	if settings.OrphanTlsHostsAction != ActionAccept {
+		t.Errorf("Wrong default for the coverage TLS mode: %v", settings.OrphanTlsHostsAction)
+	}
+
+	settings = Settings{}
+	err = json.Unmarshal([]byte(`{"tlsMode": "exact", "orphanTLSHostsAction": "warn"}`), &settings)
+	if err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+	if settings.Valid() != false {
+		t.Errorf("Settings with the exact TLS mode and a non reject orphan action are reported as Valid")
+	}
+
+	settings = Settings{}
+	err = json.Unmarshal([]byte(`{"tlsMode": "partial"}`), &settings)
+	if err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+	if settings.Valid() != false {
+		t.Errorf("Settings are reported as Valid")
+	}
+}
+
+func TestSettingsWithInvalidTlsSecretNameRules(t *testing.T) {
+	requests := []string{
+		`{"tlsSecretNamePattern": "foo-(tls"}`,
+		`{"tlsSecretNameTemplate": "foo-tls"}`,
+	}
+
+	for _, request := range requests {
+		settings := Settings{}
+		err := json.Unmarshal([]byte(request), &settings)
+		if err != nil {
+			t.Errorf("Unexpected error %+v", err)
+		}
+
+		if settings.Valid() != false {
+			t.Errorf("Settings %s are reported as Valid", request)
+		}
+	}
+}
+
+func TestSettingsWithInvalidNamespaceDomains(t *testing.T) {
+	requests := []string{
+		`{"namespaceDomains": {"team-a": ["*.corp.example"]}}`,
+		`{"namespaceDomains": {"team-a": [""]}}`,
+	}
+
+	for _, request := range requests {
+		settings := Settings{}
+		err := json.Unmarshal([]byte(request), &settings)
+		if err != nil {
+			t.Errorf("Unexpected error %+v", err)
+		}
+
+		if settings.Valid() != false {
+			t.Errorf("Settings %s are reported as Valid", request)
+		}
+	}
+}
+
+func TestSettingsWithInvalidRegistrableDomains(t *testing.T) {
+	requests := []string{
+		`{"allowedRegistrableDomains": ["co.uk"]}`,
+		`{"allowedRegistrableDomains": ["api.example.com"]}`,
+		`{"allowedRegistrableDomains": ["*.example.com"]}`,
+	}
+
+	for _, request := range requests {
+		settings := Settings{}
+		err := json.Unmarshal([]byte(request), &settings)
+		if err != nil {
+			t.Errorf("Unexpected error %+v", err)
+		}
+
+		if settings.Valid() != false {
+			t.Errorf("Settings %s are reported as Valid", request)
+		}
+	}
+
+	settings := Settings{}
+	if err := json.Unmarshal([]byte(`{"allowedRegistrableDomains": ["example.co.uk", "Example.com"]}`), &settings); err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+	if err := settings.Validate(); err != nil {
+		t.Errorf("Settings should be valid: %+v", err)
+	}
+}
+
+func TestSettingsWithInvalidProtectedDomains(t *testing.T) {
+	requests := []string{
+		`{"protectedDomains": [""]}`,
+		`{"protectedDomains": ["*.example.com"]}`,
+	}
+
+	for _, request := range requests {
+		settings := Settings{}
+		err := json.Unmarshal([]byte(request), &settings)
+		if err != nil {
+			t.Errorf("Unexpected error %+v", err)
+		}
+
+		if settings.Valid() != false {
+			t.Errorf("Settings %s are reported as Valid", request)
+		}
+	}
+}
+
+func TestSettingsWithInvalidReservedHostSuffixes(t *testing.T) {
+	requests := []string{
+		`{"reservedHostSuffixes": ["."]}`,
+		`{"reservedHostSuffixes": ["*.corp.example"]}`,
+	}
+
+	for _, request := range requests {
+		settings := Settings{}
+		err := json.Unmarshal([]byte(request), &settings)
+		if err != nil {
+			t.Errorf("Unexpected error %+v", err)
+		}
+
+		if settings.Valid() != false {
+			t.Errorf("Settings %s are reported as Valid", request)
+		}
+	}
+}
+
+func TestSettingsWithNegativeLimits(t *testing.T) {
+	settings := Settings{}
+	if err := json.Unmarshal([]byte(`{"maxPaths": -1}`), &settings); err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+	if settings.Valid() != false {
+		t.Errorf("Settings with a negative limit are reported as Valid")
+	}
+}
+
+func TestSettingsWithInvalidPathTypes(t *testing.T) {
+	settings := Settings{}
+	if err := json.Unmarshal([]byte(`{"allowedPathTypes": ["Prefix", "Regex"]}`), &settings); err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+	if settings.Valid() != false {
+		t.Errorf("Settings with an unknown path type are reported as Valid")
+	}
+}
+
+func TestDuplicateAndShadowedPathsActionsDefaultToWarn(t *testing.T) {
+	settings := Settings{}
+	if err := json.Unmarshal([]byte(`{}`), &settings); err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+	if settings.DuplicatePathsAction != ActionWarn || settings.ShadowedPathsAction != ActionWarn {
+		t.Errorf("Expected both actions to default to warn, got %s and %s", settings.DuplicatePathsAction, settings.ShadowedPathsAction)
+	}
+
+	if err := json.Unmarshal([]byte(`{"shadowedPathsAction": "ignore"}`), &settings); err != nil {
+		t.Errorf("Unexpected error %+v", err)
+	}
+	if settings.Valid() != false {
+		t.Errorf("Settings with an invalid shadowedPathsAction are reported as Valid")
+	}
+}
+
+func TestSettingsWithInvalidPathOwnership(t *testing.T) {
+	requests := []string{
+		`{"pathOwnership": {"api.corp.example": {"team-a": ["team-a"]}}}`,
+		`{"pathOwnership": {"api.corp.example": {"/team-a": []}}}`,
+		`{"pathOwnership": {"api.corp.example": {"/team-a": ["team-a"], "/team-a/": ["team-b"]}}}`,
+	}
+
+	for _, request := range requests {
+		settings := Settings{}
+		err := json.Unmarshal([]byte(request), &settings)
+		if err != nil {
+			t.Errorf("Unexpected error %+v", err)
+		}
+
+		if settings.Valid() != false {
+			t.Errorf("Settings %s are reported as Valid", request)
+		}
+	}
+}
+
+func TestSettingsWithInvalidIngressClasses(t *testing.T) {
+	requests := []string{
+		`{"allowedIngressClasses": [""]}`,
+		`{"namespaceIngressClasses": {"team-a": []}}`,
+		`{"namespaceIngressClasses": {"team-a": ["nginx", ""]}}`,
+	}
+
+	for _, request := range requests {
+		settings := Settings{}
+		err := json.Unmarshal([]byte(request), &settings)
+		if err != nil {
+			t.Errorf("Unexpected error %+v", err)
+		}
+
+		if settings.Valid() != false {
+			t.Errorf("Settings %s are reported as Valid", request)
+		}
+	}
+}
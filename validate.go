@@ -2,7 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/kubewarden/gjson"
@@ -27,30 +30,146 @@ func validate(payload []byte) ([]byte, error) {
 			kubewarden.Code(400))
 	}
 
-	if !checkTlsSettings(payload, &settings) {
+	if err := checkIngressClass(payload, validationRequest.Request.Namespace, &settings); err != nil {
 		return kubewarden.RejectRequest(
-			kubewarden.Message("Not all hosts have TLS enabled"),
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkLimits(payload, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkRequireHost(payload, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	hosts := parseHosts(payload)
+	catchAll := catchAllFields(payload)
+	if err := checkHostnames(hosts, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkReservedHosts(hosts, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkPublicSuffixes(hosts, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkProtectedDomains(hosts, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkAllowedHosts(hosts, catchAll, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
 			kubewarden.NoCode)
 	}
 
-	ports := parsePorts(payload)
-	if err := checkAllowedPorts(ports, &settings); err != nil {
+	if err := checkWildcardHosts(hosts, &validationRequest.Request, &settings); err != nil {
 		return kubewarden.RejectRequest(
 			kubewarden.Message(err.Error()),
 			kubewarden.NoCode)
 	}
-	if err := checkDeniedPorts(ports, &settings); err != nil {
+
+	if err := checkNamespaceDomains(hosts, catchAll, validationRequest.Request.Namespace, &settings); err != nil {
 		return kubewarden.RejectRequest(
 			kubewarden.Message(err.Error()),
 			kubewarden.NoCode)
 	}
 
+	if err := checkNamespaceAllowedHosts(hosts, catchAll, validationRequest.Request.Namespace, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkPaths(payload, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkStrictPaths(payload, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkDuplicatePaths(payload, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkPathOwnership(payload, validationRequest.Request.Namespace, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkHostPathCollisions(payload, validationRequest.Request.Namespace, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkTlsSettings(payload, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkTlsSecretNames(payload, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkTlsSecrets(payload, validationRequest.Request.Namespace, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkBackendPorts(rulesBackends(payload), &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.NoCode)
+	}
+
+	if err := checkDefaultBackend(payload, &settings); err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(fmt.Sprintf("default backend: %s", err)),
+			kubewarden.NoCode)
+	}
+
 	return kubewarden.AcceptRequest()
 }
 
-func checkTlsSettings(payload []byte, settings *Settings) bool {
+// All the hosts defined inside of the rules must be covered by the TLS
+// section, taking wildcard TLS hosts into account. The rules without a
+// host are handled according to the HostlessRulesTlsAction setting.
+// The TLS hosts that are not used by any rule are rejected with the
+// exact TLS mode, and reported according to the OrphanTlsHostsAction
+// setting otherwise.
+func checkTlsSettings(payload []byte, settings *Settings) error {
 	if !settings.RequireTls {
-		return true
+		return nil
 	}
 
 	tlsHost := mapset.NewThreadUnsafeSet[string]()
@@ -60,42 +179,154 @@ func checkTlsSettings(payload []byte, settings *Settings) bool {
 	data := gjson.GetManyBytes(
 		payload,
 		"request.object.spec.tls.#.hosts|@flatten",
-		"request.object.spec.rules.#.host|@flatten")
+		"request.object.spec.rules")
 
 	data[0].ForEach(func(_, entry gjson.Result) bool {
 		tlsHost.Add(entry.String())
 		return true
 	})
 
-	data[1].ForEach(func(_, entry gjson.Result) bool {
-		rulesHosts.Add(entry.String())
-		return true
+	hostlessRules := []string{}
+	for ruleIndex, rule := range data[1].Array() {
+		host := rule.Get("host").String()
+		if host == "" {
+			hostlessRules = append(hostlessRules, fmt.Sprintf("spec.rules[%d]", ruleIndex))
+			continue
+		}
+		rulesHosts.Add(host)
+	}
+	if err := checkHostlessRulesTls(hostlessRules, settings); err != nil {
+		return err
+	}
+
+	uncovered := mapset.NewThreadUnsafeSet[string]()
+	rulesHosts.Each(func(host string) bool {
+		if !anyHostMatches(tlsHost, host) {
+			uncovered.Add(host)
+		}
+		return false
 	})
+	if uncovered.Cardinality() != 0 {
+		return fmt.Errorf("Not all hosts have TLS enabled: %v", uncovered)
+	}
 
-	return tlsHost.Equal(rulesHosts)
+	orphans := mapset.NewThreadUnsafeSet[string]()
+	tlsHost.Each(func(pattern string) bool {
+		if !hostMatchesAny(pattern, rulesHosts) {
+			orphans.Add(pattern)
+		}
+		return false
+	})
+	if orphans.Cardinality() == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("these TLS hosts are not used by any rule: %v", orphans)
+	if settings.TlsMode == TlsModeExact {
+		return errors.New(msg)
+	}
+	return settings.OrphanTlsHostsAction.report(msg)
 }
 
-func parsePorts(payload []byte) mapset.Set[uint64] {
-	ports := mapset.NewThreadUnsafeSet[uint64]()
+// A rule without a host matches all the hosts, hence no TLS entry can
+// cover it
+func checkHostlessRulesTls(hostlessRules []string, settings *Settings) error {
+	if len(hostlessRules) == 0 {
+		return nil
+	}
 
-	// still using gjson because it makes the code more compact
-	data := gjson.GetBytes(
-		payload,
-		"request.object.spec.rules.#.http.paths.#.backend.service.port.number|@flatten")
-	data.ForEach(func(_, entry gjson.Result) bool {
-		ports.Add(entry.Uint())
-		return true
+	msg := fmt.Sprintf("TLS cannot be enabled for rules without a host: %s", strings.Join(hostlessRules, ", "))
+	return settings.HostlessRulesTlsAction.report(msg)
+}
+
+// rulesBackends returns the backends of the paths defined inside of
+// spec.rules
+func rulesBackends(payload []byte) []gjson.Result {
+	backends := []gjson.Result{}
+	forEachPath(gjson.GetBytes(payload, "request.object.spec.rules"), func(_, _ int, _, path gjson.Result) {
+		backends = append(backends, path.Get("backend"))
 	})
+	return backends
+}
+
+// defaultBackends returns spec.defaultBackend, when defined
+func defaultBackends(payload []byte) []gjson.Result {
+	backend := gjson.GetBytes(payload, "request.object.spec.defaultBackend")
+	if !backend.Exists() {
+		return []gjson.Result{}
+	}
+	return []gjson.Result{backend}
+}
 
+func parsePorts(backends []gjson.Result) mapset.Set[uint64] {
+	ports := mapset.NewThreadUnsafeSet[uint64]()
+	for _, backend := range backends {
+		if port := backend.Get("service.port.number"); port.Exists() {
+			ports.Add(port.Uint())
+		}
+	}
 	return ports
 }
 
+func parsePortNames(backends []gjson.Result) mapset.Set[string] {
+	names := mapset.NewThreadUnsafeSet[string]()
+	for _, backend := range backends {
+		if name := backend.Get("service.port.name"); name.Exists() {
+			names.Add(name.String())
+		}
+	}
+	return names
+}
+
+func checkBackendPorts(backends []gjson.Result, settings *Settings) error {
+	ports := parsePorts(backends)
+	if err := checkAllowedPorts(ports, settings); err != nil {
+		return err
+	}
+	if err := checkDeniedPorts(ports, settings); err != nil {
+		return err
+	}
+
+	portNames := parsePortNames(backends)
+	if err := checkDeniedPortNames(portNames, settings); err != nil {
+		return err
+	}
+	return checkAllowedPortNames(portNames, settings)
+}
+
+// The default backend is subject to the same checks of the backends
+// defined inside of the rules
+func checkDefaultBackend(payload []byte, settings *Settings) error {
+	data := gjson.GetManyBytes(
+		payload,
+		"request.object.spec.defaultBackend",
+		"request.object.spec.tls")
+	if !data[0].Exists() {
+		return nil
+	}
+
+	if settings.DenyDefaultBackend {
+		return errors.New("the usage of a default backend is not allowed")
+	}
+
+	if settings.RequireTls && len(data[1].Array()) == 0 {
+		return errors.New("TLS is required, but spec.tls is empty")
+	}
+
+	return checkBackendPorts(defaultBackends(payload), settings)
+}
+
 func checkAllowedPorts(ports mapset.Set[uint64], settings *Settings) error {
-	if settings.AllowPorts.Cardinality() == 0 {
+	if len(settings.AllowPorts) == 0 {
 		return nil
 	}
 
-	notAllowed := ports.Difference(settings.AllowPorts)
+	notAllowed := mapset.NewThreadUnsafeSet[uint64]()
+	ports.Each(func(port uint64) bool {
+		if !settings.AllowPorts.Contains(port) {
+			notAllowed.Add(port)
+		}
+		return false
+	})
 	if notAllowed.Cardinality() == 0 {
 		return nil
 	}
@@ -104,14 +335,68 @@ func checkAllowedPorts(ports mapset.Set[uint64], settings *Settings) error {
 }
 
 func checkDeniedPorts(ports mapset.Set[uint64], settings *Settings) error {
-	if settings.DenyPorts.Cardinality() == 0 {
+	if len(settings.DenyPorts) == 0 {
 		return nil
 	}
 
-	denied := ports.Intersect(settings.DenyPorts)
+	sortedPorts := ports.ToSlice()
+	sort.Slice(sortedPorts, func(i, j int) bool { return sortedPorts[i] < sortedPorts[j] })
+
+	denied := []string{}
+	for _, port := range sortedPorts {
+		portRange, found := settings.DenyPorts.Match(port)
+		if !found {
+			continue
+		}
+		if portRange.First == portRange.Last {
+			denied = append(denied, portRange.String())
+		} else {
+			denied = append(denied, fmt.Sprintf("%d (range %s)", port, portRange))
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("these ports are explicitly denied: %s", strings.Join(denied, ", "))
+}
+
+func checkDeniedPortNames(names mapset.Set[string], settings *Settings) error {
+	if settings.DenyPortNames.Cardinality() == 0 {
+		return nil
+	}
+
+	denied := names.Intersect(settings.DenyPortNames)
 	if denied.Cardinality() == 0 {
 		return nil
 	}
 
-	return fmt.Errorf("these ports are explicitly denied: %v", denied)
+	return fmt.Errorf("these named ports are explicitly denied: %v", denied)
+}
+
+// Named ports are checked against the named port lists. When a numeric
+// list is provided without the matching named port list, the port
+// number behind the name cannot be known, hence the NamedPortsAction
+// setting decides what to do. The allow and the deny sides are checked
+// independently of each other.
+func checkAllowedPortNames(names mapset.Set[string], settings *Settings) error {
+	if names.Cardinality() == 0 {
+		return nil
+	}
+
+	if settings.AllowPortNames.Cardinality() != 0 {
+		notAllowed := names.Difference(settings.AllowPortNames)
+		if notAllowed.Cardinality() != 0 {
+			return fmt.Errorf("these named ports are not on the allowed list: %v", notAllowed)
+		}
+	}
+
+	uncheckedAllow := len(settings.AllowPorts) != 0 && settings.AllowPortNames.Cardinality() == 0
+	uncheckedDeny := len(settings.DenyPorts) != 0 && settings.DenyPortNames.Cardinality() == 0
+	if !uncheckedAllow && !uncheckedDeny {
+		return nil
+	}
+
+	msg := fmt.Sprintf("these named ports cannot be checked against the numeric port lists: %v", names)
+	return settings.NamedPortsAction.report(msg)
 }
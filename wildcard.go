@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// wildcardMatches reports whether the pattern matches the given host.
+// The pattern is a host name that can have a wildcard, following the
+// rules of RFC 6125 section 6.4.3:
+//   - the wildcard must be the whole leftmost label, like `*.example.com`
+//   - the wildcard matches exactly one label, hence `*.example.com`
+//     matches `api.example.com`, but not `example.com` nor
+//     `v1.api.example.com`
+//
+// When the host is a wildcard too, the pattern matches it only when the
+// two are equal: `*.example.com` matches `*.example.com`, while
+// `api.example.com` does not.
+// Host names are compared case insensitively.
+func wildcardMatches(pattern, host string) bool {
+	if strings.EqualFold(pattern, host) {
+		return true
+	}
+
+	patternSuffix, isWildcard := strings.CutPrefix(pattern, "*.")
+	if !isWildcard || patternSuffix == "" || strings.HasPrefix(host, "*.") {
+		return false
+	}
+
+	label, hostSuffix, found := strings.Cut(host, ".")
+	if !found || label == "" {
+		return false
+	}
+
+	return strings.EqualFold(patternSuffix, hostSuffix)
+}
+
+// anyHostMatches reports whether one of the patterns matches the host
+func anyHostMatches(patterns mapset.Set[string], host string) bool {
+	matched := false
+	patterns.Each(func(pattern string) bool {
+		matched = wildcardMatches(pattern, host)
+		return matched
+	})
+	return matched
+}
+
+// hostMatchesAny reports whether the pattern matches one of the hosts
+func hostMatchesAny(pattern string, hosts mapset.Set[string]) bool {
+	matched := false
+	hosts.Each(func(host string) bool {
+		matched = wildcardMatches(pattern, host)
+		return matched
+	})
+	return matched
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestWildcardMatches(t *testing.T) {
+	matching := [][2]string{
+		{"api.example.com", "api.example.com"},
+		{"API.example.com", "api.example.com"},
+		{"*.example.com", "api.example.com"},
+		{"*.example.com", "*.example.com"},
+	}
+	for _, pair := range matching {
+		if !wildcardMatches(pair[0], pair[1]) {
+			t.Errorf("%s should match %s", pair[0], pair[1])
+		}
+	}
+
+	notMatching := [][2]string{
+		{"*.example.com", "example.com"},
+		{"*.example.com", "v1.api.example.com"},
+		{"*.example.com", "*.api.example.com"},
+		{"*.api.example.com", "*.example.com"},
+		{"api.example.com", "*.example.com"},
+		{"a*.example.com", "api.example.com"},
+		{"api.*.com", "api.example.com"},
+		{"*", "localhost"},
+		{"*.example.com", ".example.com"},
+	}
+	for _, pair := range notMatching {
+		if wildcardMatches(pair[0], pair[1]) {
+			t.Errorf("%s should not match %s", pair[0], pair[1])
+		}
+	}
+}